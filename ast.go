@@ -0,0 +1,231 @@
+package calc
+
+import "github.com/sergeysuprunchuk/calc/ast"
+
+/*
+Parse возвращает дерево выражения для анализа вне пакета. внутренние узлы
+хранят только позицию оператора или первого токена, поэтому конец узла
+находится повторным чтением токенов исходного текста: для литералов
+и идентификаторов — один токен, для вызовов, списков, словарей и индексов —
+до парной закрывающей скобки, для остальных — конец последнего потомка.
+скобки вокруг потомка входят в участок родителя: у (a + b) * c участок
+умножения начинается с '(', а участок сложения — с a.
+*/
+
+// Parse разбирает выражение и возвращает его дерево без свёртки констант:
+// 2 + 3 остаётся бинарным выражением, а не литералом 5.
+// из опций учитывается только DecimalMode, который меняет тип числовых литералов.
+func Parse(src string, opts ...Option) (ast.Node, error) {
+	parens := map[node]Pos{}
+	root, _, err := parseSource(src, opts, parens)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &converter{data: []rune(src), parens: parens, outer: map[ast.Node]ast.Span{}}
+	return c.convert(root), nil
+}
+
+var astOps = [...]ast.Op{
+	addOp:      ast.Add,
+	subOp:      ast.Sub,
+	mulOp:      ast.Mul,
+	divOp:      ast.Div,
+	powOp:      ast.Pow,
+	eqOp:       ast.Eq,
+	notEqOp:    ast.NotEq,
+	lessOp:     ast.Less,
+	lessEqOp:   ast.LessEq,
+	moreOp:     ast.More,
+	moreEqOp:   ast.MoreEq,
+	andOp:      ast.And,
+	orOp:       ast.Or,
+	notOp:      ast.Not,
+	modOp:      ast.Mod,
+	floorDivOp: ast.FloorDiv,
+	bitAndOp:   ast.BitAnd,
+	bitOrOp:    ast.BitOr,
+	bitXorOp:   ast.BitXor,
+	bitNotOp:   ast.BitNot,
+	shlOp:      ast.Shl,
+	shrOp:      ast.Shr,
+	inOp:       ast.In,
+	notInOp:    ast.NotIn,
+}
+
+type converter struct {
+	data   []rune
+	parens map[node]Pos          //позиции '(' вокруг узлов из parser.parens
+	outer  map[ast.Node]ast.Span //участки узлов вместе со скобками
+}
+
+// tokenizer возвращает токенизатор, установленный на позицию pos.
+func (c *converter) tokenizer(pos Pos) *tokenizer {
+	return &tokenizer{data: c.data, cursor: pos.Offset, line: pos.Line, column: pos.Column}
+}
+
+// skip возвращает позицию после count токенов, начиная с pos.
+func (c *converter) skip(pos Pos, count int) Pos {
+	t := c.tokenizer(pos)
+	for range count {
+		t.nextTok()
+	}
+	return t.position()
+}
+
+// closing возвращает позицию после скобки, парной первой открывающей скобке после pos.
+func (c *converter) closing(pos Pos) Pos {
+	t := c.tokenizer(pos)
+	depth := 0
+	for {
+		switch t.nextTok().typ {
+		case lParenTyp, lBracketTyp, lBraceTyp:
+			depth++
+		case rParenTyp, rBracketTyp, rBraceTyp:
+			if depth--; depth == 0 {
+				return t.position()
+			}
+		case eofTyp, errTyp:
+			//недостижимо для разобранного выражения
+			return t.position()
+		}
+	}
+}
+
+func (c *converter) leaf(pos Pos) ast.Span {
+	return ast.Span{Start: pos, End: c.skip(pos, 1)}
+}
+
+// start и end возвращают границы узла вместе со скобками вокруг него.
+func (c *converter) start(n ast.Node) Pos {
+	if s, ok := c.outer[n]; ok {
+		return s.Start
+	}
+	return n.Pos()
+}
+
+func (c *converter) end(n ast.Node) Pos {
+	if s, ok := c.outer[n]; ok {
+		return s.End
+	}
+	return n.End()
+}
+
+// span — участок от начала from до конца to.
+func (c *converter) span(from, to ast.Node) ast.Span {
+	return ast.Span{Start: c.start(from), End: c.end(to)}
+}
+
+func (c *converter) list(nodes []node) []ast.Node {
+	list := make([]ast.Node, len(nodes))
+	for i, n := range nodes {
+		list[i] = c.convert(n)
+	}
+	return list
+}
+
+func (c *converter) convert(n node) ast.Node {
+	res := c.node(n)
+	if pos, ok := c.parens[n]; ok {
+		c.outer[res] = ast.Span{Start: pos, End: c.closing(pos)}
+	}
+	return res
+}
+
+func (c *converter) node(n node) ast.Node {
+	switch n := n.(type) {
+	case *intNode:
+		return &ast.Literal{Span: c.leaf(n.pos), Kind: ast.Int, Value: n.val}
+
+	case *numNode:
+		return &ast.Literal{Span: c.leaf(n.pos), Kind: ast.Float, Value: n.val}
+
+	case *decNode:
+		return &ast.Literal{Span: c.leaf(n.pos), Kind: ast.Decimal, Value: n.val}
+
+	case *strNode:
+		return &ast.Literal{Span: c.leaf(n.pos), Kind: ast.String, Value: n.val}
+
+	case *nullNode:
+		return &ast.Literal{Span: c.leaf(n.pos), Kind: ast.Null}
+
+	case *identNode:
+		return &ast.Ident{Span: c.leaf(n.pos), Name: n.val}
+
+	case *unaryNode:
+		x := c.convert(n.val)
+		return &ast.Unary{Span: ast.Span{Start: n.pos, End: c.end(x)}, Op: astOps[n.op], X: x}
+
+	case *binaryNode:
+		x, y := c.convert(n.left), c.convert(n.right)
+		return &ast.Binary{Span: c.span(x, y), Op: astOps[n.op], OpPos: n.pos, X: x, Y: y}
+
+	case *matchNode:
+		op := ast.Match
+		if n.negate {
+			op = ast.NotMatch
+		}
+		x, y := c.convert(n.val), c.convert(n.pattern)
+		return &ast.Binary{Span: c.span(x, y), Op: op, OpPos: n.pos, X: x, Y: y}
+
+	case *coalesceNode:
+		x, y := c.convert(n.left), c.convert(n.right)
+		return &ast.Binary{Span: c.span(x, y), Op: ast.Coalesce, OpPos: n.pos, X: x, Y: y}
+
+	case *ternaryNode:
+		cond, ifTrue, ifFalse := c.convert(n.cond), c.convert(n.ifTrue), c.convert(n.ifFalse)
+		return &ast.Ternary{Span: c.span(cond, ifFalse), Cond: cond, Then: ifTrue, Else: ifFalse}
+
+	case *listNode:
+		return &ast.List{Span: ast.Span{Start: n.pos, End: c.closing(n.pos)}, Items: c.list(n.items)}
+
+	case *mapNode:
+		return &ast.Map{
+			Span:   ast.Span{Start: n.pos, End: c.closing(n.pos)},
+			Keys:   append([]string(nil), n.keys...),
+			Values: c.list(n.vals),
+		}
+
+	case *memberNode:
+		x := c.convert(n.val)
+		//'.' или '?.' и имя поля
+		return &ast.Member{Span: ast.Span{Start: c.start(x), End: c.skip(n.pos, 2)}, X: x, Name: n.name, Optional: n.optional}
+
+	case *indexNode:
+		x := c.convert(n.val)
+		return &ast.Index{Span: ast.Span{Start: c.start(x), End: c.closing(n.pos)}, X: x, Index: c.convert(n.index)}
+
+	case *sliceNode:
+		x := c.convert(n.val)
+		slice := &ast.Slice{Span: ast.Span{Start: c.start(x), End: c.closing(n.pos)}, X: x}
+		if n.from != nil {
+			slice.From = c.convert(n.from)
+		}
+		if n.to != nil {
+			slice.To = c.convert(n.to)
+		}
+		return slice
+
+	case *callNode:
+		return &ast.Call{Span: ast.Span{Start: n.pos, End: c.closing(n.pos)}, Func: n.name, Args: c.list(n.args)}
+
+	case *lambdaNode:
+		body := c.convert(n.body)
+		return &ast.Lambda{
+			Span:   ast.Span{Start: n.pos, End: c.end(body)},
+			Params: append([]string(nil), n.params...),
+			Body:   body,
+		}
+
+	case *letNode:
+		val, body := c.convert(n.val), c.convert(n.body)
+		return &ast.Let{Span: ast.Span{Start: n.pos, End: c.end(body)}, Name: n.name, Value: val, Body: body}
+
+	case *seqNode:
+		first, rest := c.convert(n.first), c.convert(n.rest)
+		return &ast.Seq{Span: c.span(first, rest), First: first, Rest: rest}
+
+	default:
+		panic("calc: Parse: неизвестный узел")
+	}
+}
@@ -0,0 +1,245 @@
+// Package ast описывает дерево выражения calc для анализа вне пакета:
+// линтеров, миграций, подсветки. дерево строит calc.Parse.
+package ast
+
+import "fmt"
+
+// Pos — позиция в исходном тексте, она же calc.Pos в ошибках. Offset считается
+// в рунах от начала, Line и Column начинаются с единицы.
+type Pos struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+func (p Pos) String() string { return fmt.Sprintf("%d:%d", p.Line, p.Column) }
+
+// Span — участок исходного текста узла: Start — первая руна, End — позиция сразу после узла.
+// скобки вокруг подвыражения в дерево не попадают и в Span самого подвыражения
+// не входят, но входят в Span родителя.
+type Span struct {
+	Start Pos
+	End   Pos
+}
+
+// Node — узел дерева выражения.
+type Node interface {
+	Pos() Pos //начало узла
+	End() Pos //позиция сразу после узла
+}
+
+// Op — оператор унарного или бинарного выражения.
+type Op uint8
+
+const (
+	Add      Op = iota + 1 // +
+	Sub                    // -
+	Mul                    // *
+	Div                    // /
+	FloorDiv               // //
+	Mod                    // %
+	Pow                    // **
+	Eq                     // ==
+	NotEq                  // !=
+	Less                   // <
+	LessEq                 // <=
+	More                   // >
+	MoreEq                 // >=
+	And                    // &&
+	Or                     // ||
+	Not                    // !
+	BitAnd                 // &
+	BitOr                  // |
+	BitXor                 // ^
+	BitNot                 // ~
+	Shl                    // <<
+	Shr                    // >>
+	In                     // in
+	NotIn                  // not in
+	Match                  // =~
+	NotMatch               // !~
+	Coalesce               // ??
+)
+
+var opNames = [...]string{
+	Add:      "+",
+	Sub:      "-",
+	Mul:      "*",
+	Div:      "/",
+	FloorDiv: "//",
+	Mod:      "%",
+	Pow:      "**",
+	Eq:       "==",
+	NotEq:    "!=",
+	Less:     "<",
+	LessEq:   "<=",
+	More:     ">",
+	MoreEq:   ">=",
+	And:      "&&",
+	Or:       "||",
+	Not:      "!",
+	BitAnd:   "&",
+	BitOr:    "|",
+	BitXor:   "^",
+	BitNot:   "~",
+	Shl:      "<<",
+	Shr:      ">>",
+	In:       "in",
+	NotIn:    "not in",
+	Match:    "=~",
+	NotMatch: "!~",
+	Coalesce: "??",
+}
+
+// String возвращает запись оператора в выражении.
+func (op Op) String() string {
+	if int(op) < len(opNames) && opNames[op] != "" {
+		return opNames[op]
+	}
+	return fmt.Sprintf("Op(%d)", op)
+}
+
+// LitKind — вид литерала.
+type LitKind uint8
+
+const (
+	Int     LitKind = iota + 1 // целое число, Value — int64
+	Float                      // дробное число, Value — float64
+	Decimal                    // число в DecimalMode, Value — calc.Decimal
+	String                     // строка, Value — string
+	Null                       // null, Value — nil
+)
+
+type (
+	// Literal — число, строка или null.
+	Literal struct {
+		Span  Span
+		Kind  LitKind
+		Value any
+	}
+
+	// Ident — идентификатор из Namespace, переменная let или параметр лямбды.
+	Ident struct {
+		Span Span
+		Name string
+	}
+
+	// Unary — унарное выражение: -x, !x, ~x.
+	Unary struct {
+		Span Span
+		Op   Op
+		X    Node
+	}
+
+	// Binary — бинарное выражение, включая =~, !~ и ??.
+	Binary struct {
+		Span  Span
+		Op    Op
+		OpPos Pos
+		X     Node
+		Y     Node
+	}
+
+	// Ternary — cond ? then : else.
+	Ternary struct {
+		Span Span
+		Cond Node
+		Then Node
+		Else Node
+	}
+
+	// List — литерал списка [a, b].
+	List struct {
+		Span  Span
+		Items []Node
+	}
+
+	// Map — литерал словаря {"a": 1, b: 2}. Keys и Values идут в порядке записи.
+	Map struct {
+		Span   Span
+		Keys   []string
+		Values []Node
+	}
+
+	// Member — обращение к полю x.name или x?.name.
+	Member struct {
+		Span     Span
+		X        Node
+		Name     string
+		Optional bool
+	}
+
+	// Index — x[index].
+	Index struct {
+		Span  Span
+		X     Node
+		Index Node
+	}
+
+	// Slice — x[from:to], опущенная граница равна nil.
+	Slice struct {
+		Span Span
+		X    Node
+		From Node
+		To   Node
+	}
+
+	// Call — вызов функции name(args).
+	Call struct {
+		Span Span
+		Func string
+		Args []Node
+	}
+
+	// Lambda — x => body или (a, b) => body.
+	Lambda struct {
+		Span   Span
+		Params []string
+		Body   Node
+	}
+
+	// Let — let name = value; body.
+	Let struct {
+		Span  Span
+		Name  string
+		Value Node
+		Body  Node
+	}
+
+	// Seq — first; rest: first вычисляется ради ошибок, результат — rest.
+	Seq struct {
+		Span  Span
+		First Node
+		Rest  Node
+	}
+)
+
+func (n *Literal) Pos() Pos { return n.Span.Start }
+func (n *Ident) Pos() Pos   { return n.Span.Start }
+func (n *Unary) Pos() Pos   { return n.Span.Start }
+func (n *Binary) Pos() Pos  { return n.Span.Start }
+func (n *Ternary) Pos() Pos { return n.Span.Start }
+func (n *List) Pos() Pos    { return n.Span.Start }
+func (n *Map) Pos() Pos     { return n.Span.Start }
+func (n *Member) Pos() Pos  { return n.Span.Start }
+func (n *Index) Pos() Pos   { return n.Span.Start }
+func (n *Slice) Pos() Pos   { return n.Span.Start }
+func (n *Call) Pos() Pos    { return n.Span.Start }
+func (n *Lambda) Pos() Pos  { return n.Span.Start }
+func (n *Let) Pos() Pos     { return n.Span.Start }
+func (n *Seq) Pos() Pos     { return n.Span.Start }
+
+func (n *Literal) End() Pos { return n.Span.End }
+func (n *Ident) End() Pos   { return n.Span.End }
+func (n *Unary) End() Pos   { return n.Span.End }
+func (n *Binary) End() Pos  { return n.Span.End }
+func (n *Ternary) End() Pos { return n.Span.End }
+func (n *List) End() Pos    { return n.Span.End }
+func (n *Map) End() Pos     { return n.Span.End }
+func (n *Member) End() Pos  { return n.Span.End }
+func (n *Index) End() Pos   { return n.Span.End }
+func (n *Slice) End() Pos   { return n.Span.End }
+func (n *Call) End() Pos    { return n.Span.End }
+func (n *Lambda) End() Pos  { return n.Span.End }
+func (n *Let) End() Pos     { return n.Span.End }
+func (n *Seq) End() Pos     { return n.Span.End }
@@ -0,0 +1,95 @@
+package ast
+
+import "fmt"
+
+// Visitor вызывается для каждого узла в Walk. если Visit возвращает w != nil,
+// Walk обходит потомков узла с w, а затем вызывает w.Visit(nil).
+type Visitor interface {
+	Visit(n Node) (w Visitor)
+}
+
+// Walk обходит дерево в глубину, начиная с n, в порядке записи узлов в выражении.
+func Walk(v Visitor, n Node) {
+	if v = v.Visit(n); v == nil {
+		return
+	}
+
+	switch n := n.(type) {
+	case *Literal, *Ident:
+
+	case *Unary:
+		Walk(v, n.X)
+
+	case *Binary:
+		Walk(v, n.X)
+		Walk(v, n.Y)
+
+	case *Ternary:
+		Walk(v, n.Cond)
+		Walk(v, n.Then)
+		Walk(v, n.Else)
+
+	case *List:
+		walkList(v, n.Items)
+
+	case *Map:
+		walkList(v, n.Values)
+
+	case *Member:
+		Walk(v, n.X)
+
+	case *Index:
+		Walk(v, n.X)
+		Walk(v, n.Index)
+
+	case *Slice:
+		Walk(v, n.X)
+		if n.From != nil {
+			Walk(v, n.From)
+		}
+		if n.To != nil {
+			Walk(v, n.To)
+		}
+
+	case *Call:
+		walkList(v, n.Args)
+
+	case *Lambda:
+		Walk(v, n.Body)
+
+	case *Let:
+		Walk(v, n.Value)
+		Walk(v, n.Body)
+
+	case *Seq:
+		Walk(v, n.First)
+		Walk(v, n.Rest)
+
+	default:
+		panic(fmt.Sprintf("ast.Walk: неизвестный узел %T", n))
+	}
+
+	v.Visit(nil)
+}
+
+func walkList(v Visitor, list []Node) {
+	for _, n := range list {
+		Walk(v, n)
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(n Node) Visitor {
+	if f(n) {
+		return f
+	}
+	return nil
+}
+
+// Inspect обходит дерево, как Walk, и вызывает f для каждого узла.
+// если f возвращает false, потомки узла пропускаются.
+// после обхода потомков f вызывается с nil.
+func Inspect(n Node, f func(Node) bool) {
+	Walk(inspector(f), n)
+}
@@ -0,0 +1,116 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// tree — x[1:] ?? f(-y, [a], {k: b}, (p) => let v = p; v ? 1 : 2; null)
+func tree() Node {
+	return &Binary{
+		Op: Coalesce,
+		X:  &Slice{X: &Ident{Name: "x"}, From: &Literal{Kind: Int, Value: int64(1)}},
+		Y: &Call{Func: "f", Args: []Node{
+			&Unary{Op: Sub, X: &Ident{Name: "y"}},
+			&List{Items: []Node{&Ident{Name: "a"}}},
+			&Map{Keys: []string{"k"}, Values: []Node{&Ident{Name: "b"}}},
+			&Lambda{Params: []string{"p"}, Body: &Let{
+				Name:  "v",
+				Value: &Ident{Name: "p"},
+				Body: &Seq{
+					First: &Ternary{Cond: &Ident{Name: "v"}, Then: &Literal{Kind: Int, Value: int64(1)}, Else: &Literal{Kind: Int, Value: int64(2)}},
+					Rest:  &Literal{Kind: Null},
+				},
+			}},
+		}},
+	}
+}
+
+func name(n Node) string {
+	switch n := n.(type) {
+	case nil:
+		return "end"
+	case *Ident:
+		return n.Name
+	case *Literal:
+		return fmt.Sprint(n.Value)
+	case *Binary:
+		return n.Op.String()
+	case *Unary:
+		return n.Op.String()
+	case *Call:
+		return n.Func + "()"
+	default:
+		return fmt.Sprintf("%T", n)[5:]
+	}
+}
+
+type visitor []string
+
+func (v *visitor) Visit(n Node) Visitor {
+	*v = append(*v, name(n))
+	return v
+}
+
+func Test_Walk(t *testing.T) {
+	var v visitor
+	Walk(&v, tree())
+
+	expected := []string{
+		"??",
+		"Slice", "x", "end", "1", "end", "end",
+		"f()",
+		"-", "y", "end", "end",
+		"List", "a", "end", "end",
+		"Map", "b", "end", "end",
+		"Lambda", "Let", "p", "end",
+		"Seq", "Ternary", "v", "end", "1", "end", "2", "end", "end", "<nil>", "end", "end",
+		"end", "end",
+		"end",
+		"end",
+	}
+
+	if !reflect.DeepEqual([]string(v), expected) {
+		t.Errorf("Walk() = %q, expected %q", v, expected)
+	}
+}
+
+func Test_Inspect(t *testing.T) {
+	var list []string
+	Inspect(tree(), func(n Node) bool {
+		if n == nil {
+			return true
+		}
+		list = append(list, name(n))
+		//аргументы вызова пропускаются
+		_, ok := n.(*Call)
+		return !ok
+	})
+
+	expected := []string{"??", "Slice", "x", "1", "f()"}
+
+	if !reflect.DeepEqual(list, expected) {
+		t.Errorf("Inspect() = %q, expected %q", list, expected)
+	}
+}
+
+func Test_Op_String(t *testing.T) {
+	tests := []struct {
+		op       Op
+		expected string
+	}{
+		{Add, "+"},
+		{FloorDiv, "//"},
+		{NotIn, "not in"},
+		{Coalesce, "??"},
+		{0, "Op(0)"},
+		{Coalesce + 1, "Op(28)"},
+	}
+
+	for _, test := range tests {
+		if s := test.op.String(); s != test.expected {
+			t.Errorf("Op(%d).String() = %q, expected %q", test.op, s, test.expected)
+		}
+	}
+}
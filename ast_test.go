@@ -0,0 +1,142 @@
+package calc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/sergeysuprunchuk/calc/ast"
+)
+
+// nodes возвращает узлы дерева в порядке обхода в виде "тип текст",
+// где текст — участок src из Span узла.
+func nodes(src string, root ast.Node) []string {
+	data := []rune(src)
+	var list []string
+	ast.Inspect(root, func(n ast.Node) bool {
+		if n != nil {
+			list = append(list, fmt.Sprintf("%T %s", n, string(data[n.Pos().Offset:n.End().Offset])))
+		}
+		return true
+	})
+	return list
+}
+
+func Test_Parse(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected []string
+	}{
+		{"2 + 3", []string{"*ast.Binary 2 + 3", "*ast.Literal 2", "*ast.Literal 3"}},
+		{"-x * 'a'", []string{"*ast.Binary -x * 'a'", "*ast.Unary -x", "*ast.Ident x", "*ast.Literal 'a'"}},
+		{"(a + b) * c", []string{"*ast.Binary (a + b) * c", "*ast.Binary a + b", "*ast.Ident a", "*ast.Ident b", "*ast.Ident c"}},
+		{"-((x)).y", []string{"*ast.Unary -((x)).y", "*ast.Member ((x)).y", "*ast.Ident x"}},
+		{"f((a), b ? (c) : (d))", []string{
+			"*ast.Call f((a), b ? (c) : (d))", "*ast.Ident a",
+			"*ast.Ternary b ? (c) : (d)", "*ast.Ident b", "*ast.Ident c", "*ast.Ident d",
+		}},
+		{"a ? b : c ?? null", []string{
+			"*ast.Ternary a ? b : c ?? null", "*ast.Ident a", "*ast.Ident b",
+			"*ast.Binary c ?? null", "*ast.Ident c", "*ast.Literal null",
+		}},
+		{"user?.address.city", []string{
+			"*ast.Member user?.address.city", "*ast.Member user?.address", "*ast.Ident user",
+		}},
+		{"max(xs[0], xs[1:])", []string{
+			"*ast.Call max(xs[0], xs[1:])",
+			"*ast.Index xs[0]", "*ast.Ident xs", "*ast.Literal 0",
+			"*ast.Slice xs[1:]", "*ast.Ident xs", "*ast.Literal 1",
+		}},
+		{"{a: [1, (2)], \"b\": {}}", []string{
+			"*ast.Map {a: [1, (2)], \"b\": {}}", "*ast.List [1, (2)]", "*ast.Literal 1", "*ast.Literal 2", "*ast.Map {}",
+		}},
+		{"map(xs, (x, i) => x * i)", []string{
+			"*ast.Call map(xs, (x, i) => x * i)", "*ast.Ident xs",
+			"*ast.Lambda (x, i) => x * i", "*ast.Binary x * i", "*ast.Ident x", "*ast.Ident i",
+		}},
+		{"let x = 1;\nx =~ 'a'; x", []string{
+			"*ast.Let let x = 1;\nx =~ 'a'; x", "*ast.Literal 1",
+			"*ast.Seq x =~ 'a'; x", "*ast.Binary x =~ 'a'", "*ast.Ident x", "*ast.Literal 'a'", "*ast.Ident x",
+		}},
+	}
+
+	for _, test := range tests {
+		root, err := Parse(test.program)
+		if err != nil {
+			t.Errorf("Parse(%q): unexpected error %v", test.program, err)
+			continue
+		}
+
+		if list := nodes(test.program, root); !reflect.DeepEqual(list, test.expected) {
+			t.Errorf("Parse(%q) = %q, expected %q", test.program, list, test.expected)
+		}
+	}
+}
+
+func Test_Parse_nodes(t *testing.T) {
+	root, err := Parse("1 + 2.5 !~ s.name")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &ast.Binary{
+		Span:  ast.Span{Start: ast.Pos{Offset: 0, Line: 1, Column: 1}, End: ast.Pos{Offset: 17, Line: 1, Column: 18}},
+		Op:    ast.NotMatch,
+		OpPos: ast.Pos{Offset: 8, Line: 1, Column: 9},
+		X: &ast.Binary{
+			Span:  ast.Span{Start: ast.Pos{Offset: 0, Line: 1, Column: 1}, End: ast.Pos{Offset: 7, Line: 1, Column: 8}},
+			Op:    ast.Add,
+			OpPos: ast.Pos{Offset: 2, Line: 1, Column: 3},
+			X: &ast.Literal{
+				Span: ast.Span{Start: ast.Pos{Offset: 0, Line: 1, Column: 1}, End: ast.Pos{Offset: 1, Line: 1, Column: 2}},
+				Kind: ast.Int, Value: int64(1),
+			},
+			Y: &ast.Literal{
+				Span: ast.Span{Start: ast.Pos{Offset: 4, Line: 1, Column: 5}, End: ast.Pos{Offset: 7, Line: 1, Column: 8}},
+				Kind: ast.Float, Value: 2.5,
+			},
+		},
+		Y: &ast.Member{
+			Span: ast.Span{Start: ast.Pos{Offset: 11, Line: 1, Column: 12}, End: ast.Pos{Offset: 17, Line: 1, Column: 18}},
+			X: &ast.Ident{
+				Span: ast.Span{Start: ast.Pos{Offset: 11, Line: 1, Column: 12}, End: ast.Pos{Offset: 12, Line: 1, Column: 13}},
+				Name: "s",
+			},
+			Name: "name",
+		},
+	}
+
+	if !reflect.DeepEqual(root, expected) {
+		t.Errorf("Parse() = %#v, expected %#v", root, expected)
+	}
+
+	//константы не сворачиваются, DecimalMode меняет тип литерала
+	root, err = Parse("0.1", DecimalMode(4, RoundHalfEven))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lit := root.(*ast.Literal); lit.Kind != ast.Decimal {
+		t.Errorf("Parse(0.1, DecimalMode) Kind = %v, expected Decimal", lit.Kind)
+	}
+}
+
+func Test_Parse_errors(t *testing.T) {
+	tests := []struct {
+		program string
+		code    Code
+	}{
+		{"", CodeEmptyExpression},
+		{"16 + * 32", CodeUnexpectedToken},
+		{"1 ? 2", CodeUnexpectedToken},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(test.program)
+
+		var target *SyntaxError
+		if !errors.As(err, &target) || target.Code != test.code {
+			t.Errorf("Parse(%q): unexpected error %v", test.program, err)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package calc
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+/*
+функции высшего порядка над списками. последний аргумент — лямбда,
+которая получает элемент и, если объявлен второй параметр, его индекс:
+map(xs, x => x * 2), filter(xs, (x, i) => i % 2 == 0).
+*/
+
+func init() {
+	register("map", mapFunc)
+	register("filter", filter)
+	register("reduce", reduce)
+	register("any", anyFunc)
+	register("all", all)
+	register("count", count)
+	register("sortBy", sortBy)
+	register("groupBy", groupBy)
+	register("flatMap", flatMap)
+}
+
+func mapFunc(lim *limits, xs []any, f *lambda) ([]any, error) {
+	if err := lim.collectionLen(len(xs)); err != nil {
+		return nil, err
+	}
+
+	res := make([]any, len(xs))
+	for i, x := range xs {
+		val, err := f.apply(x, int64(i))
+		if err != nil {
+			return nil, err
+		}
+		res[i] = val
+	}
+	return res, nil
+}
+
+func filter(xs []any, f *lambda) ([]any, error) {
+	res := make([]any, 0, len(xs))
+	for i, x := range xs {
+		ok, err := predicate(f, x, i)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			res = append(res, x)
+		}
+	}
+	return res, nil
+}
+
+// reduce сворачивает список: reduce(xs, (acc, x) => acc + x, 0).
+// без init начальным значением становится первый элемент.
+func reduce(xs []any, f *lambda, init ...any) (any, error) {
+	if len(init) > 1 {
+		return nil, argumentCountError("ожидалось не больше 3 аргументов")
+	}
+
+	var acc any
+	var offset int
+	if len(init) == 1 {
+		acc = init[0]
+	} else {
+		if len(xs) == 0 {
+			return nil, errors.New("пустой список без начального значения")
+		}
+		acc, xs, offset = xs[0], xs[1:], 1
+	}
+
+	for i, x := range xs {
+		val, err := f.apply(acc, x, int64(i+offset))
+		if err != nil {
+			return nil, err
+		}
+		acc = val
+	}
+
+	return acc, nil
+}
+
+func anyFunc(xs []any, f *lambda) (bool, error) {
+	for i, x := range xs {
+		ok, err := predicate(f, x, i)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+func all(xs []any, f *lambda) (bool, error) {
+	for i, x := range xs {
+		ok, err := predicate(f, x, i)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func count(xs []any, f *lambda) (int, error) {
+	var n int
+	for i, x := range xs {
+		ok, err := predicate(f, x, i)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// sortBy возвращает новый список, устойчиво отсортированный по ключу f(x).
+// ключи должны быть либо все числами, либо все строками.
+func sortBy(xs []any, f *lambda) ([]any, error) {
+	keys := make([]any, len(xs))
+	for i, x := range xs {
+		key, err := f.apply(x, int64(i))
+		if err != nil {
+			return nil, err
+		}
+
+		switch key.(type) {
+		case int64, float64, Decimal, string:
+		default:
+			return nil, errors.New("ключ сортировки должен быть числом или строкой, получено " + typeName(key))
+		}
+
+		if i > 0 && typeName(key) != typeName(keys[0]) {
+			return nil, fmt.Errorf("ключи сортировки разных типов: %s и %s", typeName(keys[0]), typeName(key))
+		}
+
+		keys[i] = key
+	}
+
+	index := make([]int, len(xs))
+	for i := range index {
+		index[i] = i
+	}
+
+	sort.SliceStable(index, func(i, j int) bool {
+		switch key := keys[index[i]].(type) {
+		case float64:
+			if other, ok := keys[index[j]].(float64); ok {
+				return key < other
+			}
+			return compareNumbers(key, keys[index[j]]) < 0
+		case int64:
+			if other, ok := keys[index[j]].(int64); ok {
+				return key < other
+			}
+			return compareNumbers(key, keys[index[j]]) < 0
+		case Decimal:
+			return compareNumbers(key, keys[index[j]]) < 0
+		default:
+			return key.(string) < keys[index[j]].(string)
+		}
+	})
+
+	res := make([]any, len(xs))
+	for i, j := range index {
+		res[i] = xs[j]
+	}
+	return res, nil
+}
+
+// groupBy группирует элементы по ключу f(x), ключ приводится к строке.
+func groupBy(xs []any, f *lambda) (map[string]any, error) {
+	res := make(map[string]any)
+	for i, x := range xs {
+		val, err := f.apply(x, int64(i))
+		if err != nil {
+			return nil, err
+		}
+
+		var key string
+		switch val := val.(type) {
+		case string:
+			key = val
+		case float64:
+			key = strconv.FormatFloat(val, 'f', -1, 64)
+		case int64:
+			key = strconv.FormatInt(val, 10)
+		case Decimal:
+			key = val.String()
+		case bool:
+			key = strconv.FormatBool(val)
+		default:
+			return nil, errors.New("ключ группировки должен быть строкой, числом или bool, получено " + typeName(val))
+		}
+
+		group, _ := res[key].([]any)
+		res[key] = append(group, x)
+	}
+	return res, nil
+}
+
+func flatMap(lim *limits, xs []any, f *lambda) ([]any, error) {
+	res := make([]any, 0, len(xs))
+	for i, x := range xs {
+		val, err := f.apply(x, int64(i))
+		if err != nil {
+			return nil, err
+		}
+
+		list, ok := val.([]any)
+		if !ok {
+			return nil, errors.New("функция должна возвращать list, получено " + typeName(val))
+		}
+		if err := lim.collectionLen(len(res) + len(list)); err != nil {
+			return nil, err
+		}
+		res = append(res, list...)
+	}
+	return res, nil
+}
+
+func predicate(f *lambda, x any, i int) (bool, error) {
+	val, err := f.apply(x, int64(i))
+	if err != nil {
+		return false, err
+	}
+
+	ok, isBool := val.(bool)
+	if !isBool {
+		return false, errors.New("функция должна возвращать bool, получено " + typeName(val))
+	}
+	return ok, nil
+}
@@ -0,0 +1,98 @@
+package calc
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var orders = namespace{
+	"xs": []int{3, 1, 2},
+	"lines": []map[string]any{
+		{"sku": "A-1", "qty": 2, "price": 10.5, "active": true},
+		{"sku": "B-2", "qty": 1, "price": 4, "active": false},
+		{"sku": "A-3", "qty": 5, "price": 1, "active": true},
+	},
+	"discount": 0.1,
+}
+
+func Test_builtinList(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected any
+	}{
+		{"map(xs, x => x * 2)", []any{int64(6), int64(2), int64(4)}},
+		{"map(xs, (x, i) => x * i)", []any{int64(0), int64(1), int64(4)}},
+		{"map([], x => x)", []any{}},
+		{"filter(xs, x => x > 1)", []any{int64(3), int64(2)}},
+		{"filter(lines, l => l.active)[1].sku", "A-3"},
+		{"filter(xs, (x, i) => i != 1)", []any{int64(3), int64(2)}},
+		{"reduce(xs, (acc, x) => acc + x, 0)", int64(6)},
+		{"reduce(xs, (acc, x) => acc + x)", int64(6)},
+		{"reduce(xs, (acc, x, i) => acc + i)", int64(6)},
+		{"reduce([], (acc, x) => acc + x, 16)", int64(16)},
+		{"reduce(['a', 'b'], (acc, x) => acc + x, '>')", ">ab"},
+		{"any(xs, x => x > 2)", true},
+		{"any([], x => x > 2)", false},
+		{"all(xs, x => x > 0)", true},
+		{"all(xs, x => x > 1)", false},
+		{"count(lines, l => l.active)", int64(2)},
+		{"sortBy(xs, x => x)", []any{int64(1), int64(2), int64(3)}},
+		{"sortBy(xs, x => -x)", []any{int64(3), int64(2), int64(1)}},
+		{"map(sortBy(lines, l => l.sku), l => l.qty)", []any{int64(2), int64(5), int64(1)}},
+		{"map(groupBy(lines, l => substr(l.sku, 0, 1)).A, l => l.sku)", []any{"A-1", "A-3"}},
+		{"groupBy(xs, x => x % 2 == 0)", map[string]any{"true": []any{int64(2)}, "false": []any{int64(3), int64(1)}}},
+		{"flatMap(xs, x => [x, x])", []any{int64(3), int64(3), int64(1), int64(1), int64(2), int64(2)}},
+		{
+			"round(reduce(map(filter(lines, l => l.active), l => l.qty * l.price * (1 - discount)), (a, b) => a + b, 0), 2)",
+			23.4,
+		},
+		{"let k = 10; map(xs, x => x * k)", []any{int64(30), int64(10), int64(20)}},
+		{"let add = (a, b) => a + b; add(1, 2)", int64(3)},
+		{"let mul = k => x => x * k; let double = mul(2); double(21)", int64(42)},
+		{"let xs = [1, 2]; map(xs, xs => xs + 1)", []any{int64(2), int64(3)}},
+	}
+
+	for _, test := range tests {
+		val := Calc(test.program, orders)
+		if !reflect.DeepEqual(val, test.expected) {
+			t.Errorf("%s: got %#v, want %#v", test.program, val, test.expected)
+		}
+	}
+}
+
+func Test_builtinList_errors(t *testing.T) {
+	tests := []struct {
+		program string
+		code    Code
+	}{
+		{"filter(xs, x => x)", CodeCallFailed},
+		{"map(xs, 1)", CodeArgumentType},
+		{"map(1, x => x)", CodeArgumentType},
+		{"reduce([], (a, b) => a + b)", CodeCallFailed},
+		{"reduce(xs, (a, b) => a, 1, 2)", CodeArgumentCount},
+		{"sortBy([1, 'a'], x => x)", CodeCallFailed},
+		{"sortBy(xs, x => [x])", CodeCallFailed},
+		{"flatMap(xs, x => x)", CodeCallFailed},
+		{"groupBy(xs, x => [x])", CodeCallFailed},
+		{"let f = x => x; f(1, 2)", CodeArgumentCount},
+		{"map(xs, (a, b, c) => a)", CodeArgumentCount},
+	}
+
+	for _, test := range tests {
+		err, _ := Calc(test.program, orders).(error)
+
+		var target *CallError
+		if !errors.As(err, &target) || target.Code != test.code {
+			t.Errorf("%s: got %v, want %v", test.program, err, test.code)
+		}
+	}
+
+	//ошибка внутри лямбды возвращается как есть, со своей позицией
+	err, _ := Calc("map(lines, l => l.total)", orders).(error)
+
+	var target *MemberError
+	if !errors.As(err, &target) || target.Path != "l" || target.Pos != (Pos{Offset: 17, Line: 1, Column: 18}) {
+		t.Errorf("map(lines, l => l.total): got %#v", err)
+	}
+}
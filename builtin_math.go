@@ -0,0 +1,466 @@
+package calc
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"slices"
+	"strconv"
+)
+
+func init() {
+	register("abs", absFunc)
+	register("min", minFunc)
+	register("max", maxFunc)
+	register("round", round)
+	register("floor", floor)
+	register("ceil", ceil)
+	register("trunc", trunc)
+	register("sqrt", sqrt)
+	register("cbrt", math.Cbrt)
+	register("exp", exp)
+	register("ln", ln)
+	register("log10", log10)
+	register("log", logFunc)
+	register("hypot", math.Hypot)
+	register("clamp", clamp)
+	register("sign", sign)
+	register("mod", mod)
+	register("div", div)
+	register("rem", rem)
+
+	register("sin", math.Sin)
+	register("cos", math.Cos)
+	register("tan", math.Tan)
+	register("asin", asin)
+	register("acos", acos)
+	register("atan", math.Atan)
+	register("atan2", math.Atan2)
+	register("sinh", sinh)
+	register("cosh", cosh)
+	register("tanh", math.Tanh)
+	register("asinh", math.Asinh)
+	register("acosh", acosh)
+	register("atanh", atanh)
+}
+
+var (
+	errDomain         = errors.New("аргумент вне области определения")
+	errDivisionByZero = errors.New("деление на ноль")
+	errOverflow       = errors.New("переполнение целого числа")
+	errRange          = errors.New("результат слишком велик для float64")
+)
+
+/*
+abs, min, max, mod, div и rem считают целые аргументы в int64 и возвращают
+целое, как операторы: max(9007199254740993, 1) не теряет точность,
+а mod(7, 3) == 7 % 3 == 1. если среди аргументов есть дробное число,
+все аргументы приводятся к float64.
+
+round, floor, ceil, trunc, abs, min, max, clamp, mod, div и rem, как и операторы,
+считают в Decimal, если среди аргументов есть Decimal или включён DecimalMode:
+round округляет способом Rounding из DecimalMode, поэтому round(0.125, 2)
+с RoundHalfEven равно 0.12, а большие числа не проходят через float64.
+*/
+
+// integers возвращает аргументы как int64, если все они целые.
+func integers(xs ...number) ([]int64, bool) {
+	res := make([]int64, len(xs))
+	for i, x := range xs {
+		v, ok := x.(int64)
+		if !ok {
+			return nil, false
+		}
+		res[i] = v
+	}
+	return res, true
+}
+
+// decimals возвращает аргументы как Decimal, если среди них есть Decimal
+// или dec не nil. NaN и бесконечности остаются для float64.
+func decimals(dec *decimalContext, xs ...number) ([]Decimal, bool) {
+	found := dec != nil
+	for _, x := range xs {
+		if _, ok := x.(Decimal); ok {
+			found = true
+		}
+	}
+	if !found {
+		return nil, false
+	}
+
+	res := make([]Decimal, len(xs))
+	for i, x := range xs {
+		d, ok := decimalOf(x)
+		if !ok {
+			return nil, false
+		}
+		res[i] = d
+	}
+	return res, true
+}
+
+// floats приводит аргументы к float64.
+func floats(xs ...number) []float64 {
+	res := make([]float64, len(xs))
+	for i, x := range xs {
+		res[i], _ = toFloat(x)
+	}
+	return res
+}
+
+// rounding возвращает способ округления DecimalMode, а без него — как для Decimal из Namespace.
+func rounding(dec *decimalContext) Rounding {
+	if dec == nil {
+		return defaultDecimal.rounding
+	}
+	return dec.rounding
+}
+
+func absFunc(dec *decimalContext, x number) (number, error) {
+	if i, ok := x.(int64); ok {
+		if i == math.MinInt64 {
+			return nil, errOverflow
+		}
+		return absInt(i), nil
+	}
+	if d, ok := decimals(dec, x); ok {
+		return d[0].abs(), nil
+	}
+	f, _ := toFloat(x)
+	return math.Abs(f), nil
+}
+
+func minFunc(dec *decimalContext, x number, xs ...number) number {
+	all := append([]number{x}, xs...)
+	if ints, ok := integers(all...); ok {
+		return slices.Min(ints)
+	}
+	if ds, ok := decimals(dec, all...); ok {
+		return slices.MinFunc(ds, Decimal.Cmp)
+	}
+
+	res := math.Inf(1)
+	for _, v := range floats(all...) {
+		res = math.Min(res, v)
+	}
+	return res
+}
+
+func maxFunc(dec *decimalContext, x number, xs ...number) number {
+	all := append([]number{x}, xs...)
+	if ints, ok := integers(all...); ok {
+		return slices.Max(ints)
+	}
+	if ds, ok := decimals(dec, all...); ok {
+		return slices.MaxFunc(ds, Decimal.Cmp)
+	}
+
+	res := math.Inf(-1)
+	for _, v := range floats(all...) {
+		res = math.Max(res, v)
+	}
+	return res
+}
+
+/*
+round округляет до digits знаков после запятой (по умолчанию до целого),
+половина округляется от нуля. digits может быть отрицательным: round(1234, -2) == 1200.
+округление выполняется над кратчайшим десятичным представлением числа,
+поэтому round(1.005, 2) == 1.01, а не 1.0, как при умножении на 100.
+целое остаётся целым, Decimal округляется способом Rounding.
+*/
+func round(dec *decimalContext, x number, digits ...int) (number, error) {
+	if len(digits) > 1 {
+		return nil, argumentCountError("ожидалось не больше 2 аргументов")
+	}
+
+	var d int
+	if len(digits) == 1 {
+		d = digits[0]
+	}
+
+	if d < -308 || d > 308 {
+		return nil, errors.New("количество знаков должно быть от -308 до 308")
+	}
+
+	if i, ok := x.(int64); ok {
+		if d >= 0 {
+			return i, nil
+		}
+		r, _ := decimalOf(i)
+		res, ok := r.roundTo(d, RoundHalfUp).toInt()
+		if !ok {
+			return nil, errOverflow
+		}
+		return res, nil
+	}
+
+	if ds, ok := decimals(dec, x); ok {
+		return ds[0].roundTo(d, rounding(dec)), nil
+	}
+
+	f, _ := toFloat(x)
+	return roundFloat(f, d), nil
+}
+
+// roundFloat округляет x до d знаков, половина — от нуля.
+func roundFloat(x float64, d int) float64 {
+	if math.IsInf(x, 0) || math.IsNaN(x) {
+		return x
+	}
+
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(x, 'f', -1, 64))
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(d))), nil))
+
+	if d >= 0 {
+		r.Mul(r, scale)
+	} else {
+		r.Quo(r, scale)
+	}
+
+	//округление половины от нуля: trunc(r + sign(r) * 1/2)
+	half := big.NewRat(1, 2)
+	if r.Sign() < 0 {
+		half.Neg(half)
+	}
+	r.Add(r, half)
+	i := new(big.Int).Quo(r.Num(), r.Denom())
+	r.SetInt(i)
+
+	if d >= 0 {
+		r.Quo(r, scale)
+	} else {
+		r.Mul(r, scale)
+	}
+
+	f, _ := r.Float64()
+	return f
+}
+
+// floor, ceil и trunc возвращают целое без изменений.
+func floor(dec *decimalContext, x number) number {
+	return integral(dec, x, math.Floor, Decimal.floor)
+}
+
+func ceil(dec *decimalContext, x number) number {
+	return integral(dec, x, math.Ceil, Decimal.ceil)
+}
+
+func trunc(dec *decimalContext, x number) number {
+	return integral(dec, x, math.Trunc, Decimal.trunc)
+}
+
+func integral(dec *decimalContext, x number, f func(float64) float64, d func(Decimal) Decimal) number {
+	if _, ok := x.(int64); ok {
+		return x
+	}
+	if ds, ok := decimals(dec, x); ok {
+		return d(ds[0])
+	}
+	v, _ := toFloat(x)
+	return f(v)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func sqrt(x float64) (float64, error) {
+	if x < 0 {
+		return 0, errDomain
+	}
+	return math.Sqrt(x), nil
+}
+
+// finite возвращает errRange, если функция дала бесконечность: exp(1000).
+func finite(x float64) (float64, error) {
+	if math.IsInf(x, 0) {
+		return 0, errRange
+	}
+	return x, nil
+}
+
+func exp(x float64) (float64, error) { return finite(math.Exp(x)) }
+
+func sinh(x float64) (float64, error) { return finite(math.Sinh(x)) }
+
+func cosh(x float64) (float64, error) { return finite(math.Cosh(x)) }
+
+func ln(x float64) (float64, error) {
+	if x <= 0 {
+		return 0, errDomain
+	}
+	return math.Log(x), nil
+}
+
+func log10(x float64) (float64, error) {
+	if x <= 0 {
+		return 0, errDomain
+	}
+	return math.Log10(x), nil
+}
+
+func logFunc(x, base float64) (float64, error) {
+	if x <= 0 || base <= 0 || base == 1 {
+		return 0, errDomain
+	}
+	return math.Log(x) / math.Log(base), nil
+}
+
+var errClampBounds = errors.New("нижняя граница больше верхней")
+
+func clamp(dec *decimalContext, x, lo, hi number) (number, error) {
+	if v, ok := integers(x, lo, hi); ok {
+		if v[1] > v[2] {
+			return nil, errClampBounds
+		}
+		return max(v[1], min(v[0], v[2])), nil
+	}
+
+	if v, ok := decimals(dec, x, lo, hi); ok {
+		switch {
+		case v[1].Cmp(v[2]) > 0:
+			return nil, errClampBounds
+		case v[0].Cmp(v[1]) < 0:
+			return v[1], nil
+		case v[0].Cmp(v[2]) > 0:
+			return v[2], nil
+		default:
+			return v[0], nil
+		}
+	}
+
+	v := floats(x, lo, hi)
+	if v[1] > v[2] {
+		return nil, errClampBounds
+	}
+	return math.Max(v[1], math.Min(v[0], v[2])), nil
+}
+
+func sign(x float64) float64 {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	default:
+		return 0
+	}
+}
+
+/*
+floorMod — остаток от деления с округлением частного вниз (как в Python):
+знак результата совпадает со знаком делителя, поэтому -7 % 3 == 2, а 7 % -3 == -2.
+вместе с x // y выполняется x == (x // y) * y + x % y.
+остаток со знаком делимого возвращает rem.
+*/
+func floorMod(x, y float64) float64 {
+	r := math.Mod(x, y)
+	if r != 0 && (r < 0) != (y < 0) {
+		r += y
+	}
+	return r
+}
+
+func mod(dec *decimalContext, x, y number) (number, error) {
+	if v, ok := integers(x, y); ok {
+		if v[1] == 0 {
+			return nil, errDivisionByZero
+		}
+		_, m := floorDivInt(v[0], v[1])
+		return m, nil
+	}
+
+	if v, ok := decimals(dec, x, y); ok {
+		if v[1].sign() == 0 {
+			return nil, errDivisionByZero
+		}
+		return v[0].mod(v[1]), nil
+	}
+
+	v := floats(x, y)
+	if v[1] == 0 {
+		return nil, errDivisionByZero
+	}
+	return floorMod(v[0], v[1]), nil
+}
+
+func div(dec *decimalContext, x, y number) (number, error) {
+	if v, ok := integers(x, y); ok {
+		if v[1] == 0 {
+			return nil, errDivisionByZero
+		}
+		if v[0] == math.MinInt64 && v[1] == -1 {
+			return nil, errOverflow
+		}
+		q, _ := floorDivInt(v[0], v[1])
+		return q, nil
+	}
+
+	if v, ok := decimals(dec, x, y); ok {
+		if v[1].sign() == 0 {
+			return nil, errDivisionByZero
+		}
+		return v[0].floorDiv(v[1]), nil
+	}
+
+	v := floats(x, y)
+	if v[1] == 0 {
+		return nil, errDivisionByZero
+	}
+	return math.Floor(v[0] / v[1]), nil
+}
+
+func rem(dec *decimalContext, x, y number) (number, error) {
+	if v, ok := integers(x, y); ok {
+		if v[1] == 0 {
+			return nil, errDivisionByZero
+		}
+		return v[0] % v[1], nil
+	}
+
+	if v, ok := decimals(dec, x, y); ok {
+		if v[1].sign() == 0 {
+			return nil, errDivisionByZero
+		}
+		return v[0].rem(v[1]), nil
+	}
+
+	v := floats(x, y)
+	if v[1] == 0 {
+		return nil, errDivisionByZero
+	}
+	return math.Mod(v[0], v[1]), nil
+}
+
+func asin(x float64) (float64, error) {
+	if x < -1 || x > 1 {
+		return 0, errDomain
+	}
+	return math.Asin(x), nil
+}
+
+func acos(x float64) (float64, error) {
+	if x < -1 || x > 1 {
+		return 0, errDomain
+	}
+	return math.Acos(x), nil
+}
+
+func acosh(x float64) (float64, error) {
+	if x < 1 {
+		return 0, errDomain
+	}
+	return math.Acosh(x), nil
+}
+
+func atanh(x float64) (float64, error) {
+	if x <= -1 || x >= 1 {
+		return 0, errDomain
+	}
+	return math.Atanh(x), nil
+}
@@ -0,0 +1,161 @@
+package calc
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func Test_builtinMath(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected any
+	}{
+		{"abs(-16)", int64(16)},
+		{"abs(age)", int64(32)},
+		{"min(16, -32, 64)", int64(-32)},
+		{"max(16)", int64(16)},
+		{"max(16, -32, 64)", int64(64)},
+		{"round(2.5)", 3.},
+		{"round(-2.5)", -3.},
+		{"round(1.005, 2)", 1.01},
+		{"round(2.675, 2)", 2.68},
+		{"round(-1.005, 2)", -1.01},
+		{"round(19.99 * 3, 1)", 60.},
+		{"round(1234.5678, -2)", 1200.},
+		{"round(0.1 + 0.2, 10)", 0.3},
+		{"floor(-1.5)", -2.},
+		{"ceil(-1.5)", -1.},
+		{"trunc(-1.5)", -1.},
+		{"sqrt(16)", 4.},
+		{"cbrt(27)", 3.},
+		{"exp(0)", 1.},
+		{"exp(-1000)", 0.},
+		{"sinh(0)", 0.},
+		{"cosh(0)", 1.},
+		{"ln(1)", 0.},
+		{"log10(1000)", 3.},
+		{"log(8, 2)", 3.},
+		{"hypot(3, 4)", 5.},
+		{"clamp(16, 0, 10)", int64(10)},
+		{"clamp(-16, 0, 10)", int64(0)},
+		{"clamp(5, 0, 10)", int64(5)},
+		{"sign(-16)", -1.},
+		{"sign(0)", 0.},
+		{"sign(age)", 1.},
+		{"sin(0)", 0.},
+		{"cos(0)", 1.},
+		{"atan2(0, 1)", 0.},
+		{"tanh(0)", 0.},
+		{"acosh(1)", 0.},
+		{"round(asin(1) * 2, 10) == round(acos(-1), 10)", true},
+	}
+
+	for _, test := range tests {
+		val := Calc(test.program, base)
+		if !reflect.DeepEqual(val, test.expected) {
+			t.Errorf("%s: got %v, want %v", test.program, val, test.expected)
+		}
+	}
+
+	if val := Calc("tan(atan(1))", base).(float64); math.Abs(val-1) > 1e-12 {
+		t.Errorf("tan(atan(1)): got %v", val)
+	}
+}
+
+func Test_builtinMath_errors(t *testing.T) {
+	tests := []struct {
+		program string
+		code    Code
+	}{
+		{"sqrt(-1)", CodeCallFailed},
+		{"ln(0)", CodeCallFailed},
+		{"log(8, 1)", CodeCallFailed},
+		{"asin(2)", CodeCallFailed},
+		{"atanh(1)", CodeCallFailed},
+		{"exp(1000)", CodeCallFailed},
+		{"sinh(-1000)", CodeCallFailed},
+		{"cosh(1000)", CodeCallFailed},
+		{"clamp(1, 10, 0)", CodeCallFailed},
+		{"round(1, 2, 3)", CodeArgumentCount},
+		{"round(1, 0.5)", CodeArgumentType},
+		{"min()", CodeArgumentCount},
+		{"abs(name)", CodeArgumentType},
+		{"hypot(3)", CodeArgumentCount},
+	}
+
+	for _, test := range tests {
+		err, _ := Calc(test.program, base).(error)
+
+		var target *CallError
+		if !errors.As(err, &target) || target.Code != test.code {
+			t.Errorf("%s: got %v, want %v", test.program, err, test.code)
+		}
+	}
+}
+
+func Test_builtinMath_integers(t *testing.T) {
+	ns := namespace{"big": int64(9007199254740993), "least": int64(math.MinInt64), "huge": uint64(math.MaxUint64)}
+
+	tests := []struct {
+		program  string
+		expected any
+	}{
+		{"max(big, 1)", int64(9007199254740993)},
+		{"min(big, big + 1)", int64(9007199254740993)},
+		{"abs(-big)", int64(9007199254740993)},
+		{"max(1, 2.5)", 2.5},
+		{"max(3, 2.5)", 3.},
+		{"min(1.5, 2)", 1.5},
+		{"abs(-1.5)", 1.5},
+		{"mod(7, 3) == 7 % 3", true},
+		{"mod(big, 10)", int64(3)},
+		{"mod(-7.5, 2)", 0.5},
+		{"div(big, 2)", int64(4503599627370496)},
+		{"div(7.5, 2)", 3.},
+		{"rem(7, -3)", int64(1)},
+		{"rem(-7.5, 2)", -1.5},
+		{"round(big)", int64(9007199254740993)},
+		{"round(big, -1)", int64(9007199254740990)},
+		{"round(15, -1)", int64(20)},
+		{"floor(big)", int64(9007199254740993)},
+		{"ceil(-3)", int64(-3)},
+		{"trunc(7)", int64(7)},
+		{"floor(-2.5)", -3.},
+		{"clamp(big, 0, big - 1)", int64(9007199254740992)},
+		{"clamp(2.5, 0, 2)", 2.},
+		{"mod(huge, 2) == huge % 2", true},
+		{"mod(huge, 2) == 1", true},
+		{"div(huge, 2) == huge // 2", true},
+		{"div(huge, 2) == 9223372036854775807", true},
+		{"rem(huge, -10) == 5", true},
+	}
+
+	for _, test := range tests {
+		val := Calc(test.program, ns)
+		if !reflect.DeepEqual(val, test.expected) {
+			t.Errorf("%s: got %#v, want %#v", test.program, val, test.expected)
+		}
+	}
+
+	errs := []struct {
+		program string
+		err     error
+	}{
+		{"abs(least)", errOverflow},
+		{"div(least, -1)", errOverflow},
+		{"mod(big, 0)", errDivisionByZero},
+		{"rem(big, 0.0)", errDivisionByZero},
+		{"mod(huge, 0)", errDivisionByZero},
+	}
+
+	for _, test := range errs {
+		err, _ := Calc(test.program, ns).(error)
+
+		var target *CallError
+		if !errors.As(err, &target) || target.Err != test.err {
+			t.Errorf("%s: got %v, want %v", test.program, err, test.err)
+		}
+	}
+}
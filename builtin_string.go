@@ -0,0 +1,255 @@
+package calc
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+строковые функции работают с рунами, а не с байтами, как и tokenizer:
+len("привет") == 6, а индексы в indexOf и substr считаются в символах.
+len также возвращает длину списка.
+*/
+
+func init() {
+	register("len", length)
+	register("upper", strings.ToUpper)
+	register("lower", strings.ToLower)
+	register("trim", strings.TrimSpace)
+	register("contains", strings.Contains)
+	register("startsWith", strings.HasPrefix)
+	register("endsWith", strings.HasSuffix)
+	register("indexOf", indexOf)
+	register("substr", substr)
+	register("replace", replace)
+	register("split", split)
+	register("join", join)
+	register("repeat", repeat)
+	register("padLeft", padLeft)
+	register("padRight", padRight)
+	register("format", format)
+}
+
+func length(val any) (int, error) {
+	if n, ok := sequenceLen(val); ok {
+		return n, nil
+	}
+	return 0, errors.New("ожидалась строка или список, получено " + typeName(val))
+}
+
+func indexOf(s, substr string) int {
+	i := strings.Index(s, substr)
+	if i < 0 {
+		return -1
+	}
+	return utf8.RuneCountInString(s[:i])
+}
+
+/*
+substr возвращает length символов, начиная со start (по умолчанию до конца строки).
+отрицательный start отсчитывается от конца строки, выход за границы обрезается.
+*/
+func substr(s string, start int, length ...int) (string, error) {
+	if len(length) > 1 {
+		return "", argumentCountError("ожидалось не больше 3 аргументов")
+	}
+
+	runes := []rune(s)
+
+	if start < 0 {
+		start = max(len(runes)+start, 0)
+	}
+	start = min(start, len(runes))
+
+	end := len(runes)
+	if len(length) == 1 {
+		if length[0] < 0 {
+			return "", errors.New("длина не может быть отрицательной")
+		}
+		end = min(start+length[0], end)
+	}
+
+	return string(runes[start:end]), nil
+}
+
+// replace заменяет все вхождения old на new. длина результата считается
+// до замены: replace(s, "", s) растёт как квадрат длины s.
+func replace(lim *limits, s, old, new string) (string, error) {
+	runes := utf8.RuneCountInString(s)
+	n := strings.Count(s, old)
+	if growth := utf8.RuneCountInString(new) - utf8.RuneCountInString(old); growth > 0 {
+		if n > (math.MaxInt-runes)/growth {
+			return "", errStringTooLong
+		}
+		if err := lim.stringLen(runes + n*growth); err != nil {
+			return "", err
+		}
+	}
+	return strings.ReplaceAll(s, old, new), nil
+}
+
+// join склеивает строки через sep, длина результата проверяется до склейки.
+func join(lim *limits, xs []string, sep string) (string, error) {
+	if len(xs) == 0 {
+		return "", nil
+	}
+
+	n := (len(xs) - 1) * utf8.RuneCountInString(sep)
+	for _, x := range xs {
+		n += utf8.RuneCountInString(x)
+	}
+	if err := lim.stringLen(n); err != nil {
+		return "", err
+	}
+	return strings.Join(xs, sep), nil
+}
+
+func split(lim *limits, s, sep string) ([]string, error) {
+	n := strings.Count(s, sep) + 1
+	if sep == "" {
+		n = utf8.RuneCountInString(s)
+	}
+	if err := lim.collectionLen(n); err != nil {
+		return nil, err
+	}
+	return strings.Split(s, sep), nil
+}
+
+// maxBuiltinString — предел длины строк, которые строят repeat, padLeft и padRight,
+// в символах: без него repeat('ab', 1e18) переполняет длину и паникует,
+// а огромная ширина в padLeft исчерпывает память раньше, чем вернётся ошибка.
+const maxBuiltinString = 1 << 24
+
+var errStringTooLong = fmt.Errorf("строка длиннее %d символов", maxBuiltinString)
+
+func repeat(lim *limits, s string, count int) (string, error) {
+	if count < 0 {
+		return "", errors.New("количество повторений не может быть отрицательным")
+	}
+	runes := utf8.RuneCountInString(s)
+	if count > 0 && runes > maxBuiltinString/count {
+		return "", errStringTooLong
+	}
+	if err := lim.stringLen(runes * count); err != nil {
+		return "", err
+	}
+	return strings.Repeat(s, count), nil
+}
+
+func padLeft(lim *limits, s string, width int, pad ...string) (string, error) {
+	fill, err := padding(lim, s, width, pad)
+	if err != nil {
+		return "", err
+	}
+	return fill + s, nil
+}
+
+func padRight(lim *limits, s string, width int, pad ...string) (string, error) {
+	fill, err := padding(lim, s, width, pad)
+	if err != nil {
+		return "", err
+	}
+	return s + fill, nil
+}
+
+// padding возвращает заполнитель, дополняющий s до width символов.
+func padding(lim *limits, s string, width int, pad []string) (string, error) {
+	if len(pad) > 1 {
+		return "", argumentCountError("ожидалось не больше 3 аргументов")
+	}
+
+	fill := []rune(" ")
+	if len(pad) == 1 {
+		fill = []rune(pad[0])
+	}
+
+	if len(fill) == 0 {
+		return "", errors.New("заполнитель не может быть пустым")
+	}
+
+	if width > maxBuiltinString {
+		return "", errStringTooLong
+	}
+
+	n := width - utf8.RuneCountInString(s)
+	if n <= 0 {
+		return "", nil
+	}
+	if err := lim.stringLen(width); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.Grow(n/len(fill)*len(string(fill)) + len(string(fill[:n%len(fill)])))
+	for i := range n {
+		b.WriteRune(fill[i%len(fill)])
+	}
+	return b.String(), nil
+}
+
+/*
+format работает как fmt.Sprintf. целые значения float64 и Decimal для глаголов
+%d, %x, %X, %o, %b, %c и ширины * передаются как int64, любые числа для %e, %f и %g —
+как float64, а Decimal для %v и %s выводится точно.
+*/
+func format(f string, args ...any) string {
+	verbs := formatVerbs(f)
+	for i, arg := range args {
+		if i < len(verbs) && strings.ContainsRune("eEfFgG", verbs[i]) {
+			if f, ok := toFloat(arg); ok {
+				args[i] = f
+			}
+			continue
+		}
+
+		if i >= len(verbs) || !strings.ContainsRune("dxXobc*", verbs[i]) {
+			continue
+		}
+
+		if d, ok := arg.(Decimal); ok {
+			if n, ok := d.toInt(); ok {
+				args[i] = n
+			}
+			continue
+		}
+
+		if v, ok := arg.(float64); ok && v == math.Trunc(v) &&
+			v >= math.MinInt64 && v < math.MaxInt64 {
+			args[i] = int64(v)
+		}
+	}
+
+	return fmt.Sprintf(f, args...)
+}
+
+// formatVerbs возвращает глаголы формата по порядку, %% пропускается.
+func formatVerbs(f string) []rune {
+	var verbs []rune
+
+	runes := []rune(f)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '%' {
+			continue
+		}
+
+		for i++; i < len(runes); i++ {
+			if runes[i] == '*' {
+				//ширина или точность из аргумента занимает его место
+				verbs = append(verbs, '*')
+				continue
+			}
+			if strings.ContainsRune("+-# 0123456789.", runes[i]) {
+				continue
+			}
+			if runes[i] != '%' {
+				verbs = append(verbs, runes[i])
+			}
+			break
+		}
+	}
+
+	return verbs
+}
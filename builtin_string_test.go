@@ -0,0 +1,93 @@
+package calc
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_builtinString(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected any
+	}{
+		{"len(name)", int64(5)},
+		{"len('привет мир')", int64(10)},
+		{"len('')", int64(0)},
+		{"upper('привет ' + name)", "ПРИВЕТ TYSON"},
+		{"lower('ПРИВЕТ')", "привет"},
+		{"trim('  привет\t')", "привет"},
+		{"contains('привет мир', 'т м')", true},
+		{"startsWith(name, 'ty')", true},
+		{"endsWith(name, 'ty')", false},
+		{"indexOf('привет мир', 'мир')", int64(7)},
+		{"indexOf('привет', 'мир')", int64(-1)},
+		{"substr('привет мир', 7)", "мир"},
+		{"substr('привет мир', 0, 6)", "привет"},
+		{"substr('привет мир', -3)", "мир"},
+		{"substr('привет мир', -3, 1)", "м"},
+		{"substr('привет', 4, 100)", "ет"},
+		{"substr('привет', 100)", ""},
+		{"substr('привет', -100, 2)", "пр"},
+		{"replace('a-b-c', '-', '+')", "a+b+c"},
+		{"split('a,b,c', ',')", []any{"a", "b", "c"}},
+		{"join(split('a,b,c', ','), ' | ')", "a | b | c"},
+		{"repeat('ля', 3)", "ляляля"},
+		{"padLeft('7', 3, '0')", "007"},
+		{"padLeft('привет', 3)", "привет"},
+		{"padRight('ab', 5, 'ёж')", "abёжё"},
+		{"padRight(name, 7) + '|'", "tyson  |"},
+		{"len(repeat('ab', 8388608))", int64(16777216)},
+		{"format('%.2f', 2 / 3)", "0.67"},
+		{"format('%d шт. по %v', 3, 19.9)", "3 шт. по 19.9"},
+		{"format('%05d|%x|%s', age, 255, name)", "00032|ff|tyson"},
+		{"format('%d%%, %*d', 50, 4, 7)", "50%,    7"},
+		{"format('%d', 2.5)", "%!d(float64=2.5)"},
+	}
+
+	for _, test := range tests {
+		val := Calc(test.program, base)
+		if !reflect.DeepEqual(val, test.expected) {
+			t.Errorf("%s: got %#v, want %#v", test.program, val, test.expected)
+		}
+	}
+}
+
+func Test_builtinString_errors(t *testing.T) {
+	tests := []struct {
+		program string
+		code    Code
+	}{
+		{"len(16)", CodeCallFailed},
+		{"upper(16)", CodeArgumentType},
+		{"substr('привет', 1, -1)", CodeCallFailed},
+		{"substr('привет', 1, 2, 3)", CodeArgumentCount},
+		{"substr('привет', 1.5)", CodeArgumentType},
+		{"repeat('ля', -1)", CodeCallFailed},
+		{"repeat('ab', 4611686018427387904)", CodeCallFailed},
+		{"repeat('ab', 8388609)", CodeCallFailed},
+		{"padLeft('a', 10000000000000)", CodeCallFailed},
+		{"padRight('a', 16777217, 'ёж')", CodeCallFailed},
+		{"padLeft('7', 3, '')", CodeCallFailed},
+		{"padLeft('7', 3, '0', '1')", CodeArgumentCount},
+		{"format()", CodeArgumentCount},
+	}
+
+	for _, test := range tests {
+		err, _ := Calc(test.program, base).(error)
+
+		var target *CallError
+		if !errors.As(err, &target) || target.Code != test.code {
+			t.Errorf("%s: got %v, want %v", test.program, err, test.code)
+		}
+	}
+}
+
+func Test_builtinString_argumentText(t *testing.T) {
+	err, _ := Calc("join([1, 2], ',')", nil).(error)
+
+	var target *CallError
+	if !errors.As(err, &target) || target.Msg != "ожидалось list[string], получено list" {
+		t.Errorf("join([1, 2], ','): got %v", err)
+	}
+}
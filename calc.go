@@ -1,7 +1,15 @@
 package calc
 
-func Calc(program string, namespace Namespace) any {
-	return newParser(program).
-		parse().
-		exec(namespace)
+func Calc(program string, namespace Namespace, opts ...Option) any {
+	p, err := Compile(program, opts...)
+	if err != nil {
+		return err
+	}
+
+	val, err := p.Eval(namespace)
+	if err != nil {
+		return err
+	}
+
+	return val
 }
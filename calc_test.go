@@ -23,326 +23,326 @@ func Test_Calc(t *testing.T) {
 		program  string
 		expected any
 	}{
-		{"2 + 5", 7.},
-		{"3 - 4", -1.},
-		{"8 * 2", 16.},
+		{"2 + 5", int64(7)},
+		{"3 - 4", int64(-1)},
+		{"8 * 2", int64(16)},
 		{"16 / 4", 4.},
-		{"9 ** 2", 81.},
-		{"(2 + 3) * 5", 25.},
+		{"9 ** 2", int64(81)},
+		{"(2 + 3) * 5", int64(25)},
 		{"4 - 2.25", 1.75},
 		{"27 / 3", 9.},
-		{"10 + 8", 18.},
+		{"10 + 8", int64(18)},
 		{"5 * 3.09", 15.45},
-		{"64 - 16", 48.},
-		{"2 ** 3", 8.},
+		{"64 - 16", int64(48)},
+		{"2 ** 3", int64(8)},
 		{"(9 + 5) / 2", 7.},
-		{"25 * 4", 100.},
+		{"25 * 4", int64(100)},
 		{"81 / 9", 9.},
 		{"3 + 2.50", 5.5},
-		{"15 - 5", 10.},
-		{"8 ** 2", 64.},
-		{"4 * (3 + 2)", 20.},
+		{"15 - 5", int64(10)},
+		{"8 ** 2", int64(64)},
+		{"4 * (3 + 2)", int64(20)},
 		{"32 / 8", 4.},
-		{"5 + 9", 14.},
+		{"5 + 9", int64(14)},
 		{"2.25 * 4", 9.},
-		{"16 - 3", 13.},
-		{"10 ** 2", 100.},
-		{"(5 * 2) + 3", 13.},
-		{"27 + 9", 36.},
+		{"16 - 3", int64(13)},
+		{"10 ** 2", int64(100)},
+		{"(5 * 2) + 3", int64(13)},
+		{"27 + 9", int64(36)},
 		{"4 / 2", 2.},
 		{"8 + 5.25", 13.25},
-		{"3 * 16", 48.},
+		{"3 * 16", int64(48)},
 		{"64 / 2", 32.},
 		{"9 - 2.09", 6.91},
-		{"25 + 5", 30.},
-		{"2 * (8 + 4)", 24.},
-		{"81 ** 2", 6561.},
+		{"25 + 5", int64(30)},
+		{"2 * (8 + 4)", int64(24)},
+		{"81 ** 2", int64(6561)},
 		{"15 / 3", 5.},
-		{"5 - 2", 3.},
+		{"5 - 2", int64(3)},
 		{"4 + 3.50", 7.5},
-		{"32 * 2", 64.},
-		{"10 - 8", 2.},
-		{"3 ** 3", 27.},
-		{"(9 * 2) - 5", 13.},
-		{"16 + 4", 20.},
+		{"32 * 2", int64(64)},
+		{"10 - 8", int64(2)},
+		{"3 ** 3", int64(27)},
+		{"(9 * 2) - 5", int64(13)},
+		{"16 + 4", int64(20)},
 		{"2 / 2", 1.},
 		{"8 * 3.25", 26.},
-		{"27 - 9", 18.},
+		{"27 - 9", int64(18)},
 		{"5 + 2.09", 7.09},
-		{"64 + 16", 80.},
-		{"4 ** 2", 16.},
+		{"64 + 16", int64(80)},
+		{"4 ** 2", int64(16)},
 		{"25 / 5", 5.},
-		{"(3 + 5) * 2", 16.},
-		{"9 + 8", 17.},
+		{"(3 + 5) * 2", int64(16)},
+		{"9 + 8", int64(17)},
 		{"2 - 0.25", 1.75},
 		{"81 / 3", 27.},
-		{"10 * 4", 40.},
-		{"5 ** 2", 25.},
-		{"32 - 8", 24.},
+		{"10 * 4", int64(40)},
+		{"5 ** 2", int64(25)},
+		{"32 - 8", int64(24)},
 		{"(16 + 2) / 3", 6.},
 		{"3 * 5.50", 16.5},
-		{"4 + 9", 13.},
+		{"4 + 9", int64(13)},
 		{"8 - 2.09", 5.91},
 		{"27 / 9", 3.},
-		{"2 + 15", 17.},
-		{"64 * 2", 128.},
+		{"2 + 15", int64(17)},
+		{"64 * 2", int64(128)},
 		{"5 + 3.25", 8.25},
-		{"9 ** 3", 729.},
-		{"(4 * 5) - 2", 18.},
+		{"9 ** 3", int64(729)},
+		{"(4 * 5) - 2", int64(18)},
 		{"16 / 8", 2.},
-		{"25 + 3", 28.},
+		{"25 + 3", int64(28)},
 		{"2 * 2.50", 5.},
-		{"81 - 27", 54.},
+		{"81 - 27", int64(54)},
 		{"10 / 2", 5.},
-		{"3 + 8", 11.},
-		{"32 + 4", 36.},
-		{"5 * (2 + 3)", 25.},
-		{"8 ** 3", 512.},
+		{"3 + 8", int64(11)},
+		{"32 + 4", int64(36)},
+		{"5 * (2 + 3)", int64(25)},
+		{"8 ** 3", int64(512)},
 		{"4 - 0.09", 3.91},
 		{"9 + 5.25", 14.25},
-		{"16 * 2", 32.},
-		{"27 - 3", 24.},
+		{"16 * 2", int64(32)},
+		{"27 - 3", int64(24)},
 		{"2 + 2.09", 4.09},
 		{"64 / 4", 16.},
-		{"5 + 10", 15.},
-		{"3 * 4", 12.},
-		{"25 ** 2", 625.},
-		{"(8 + 2) * 3", 30.},
+		{"5 + 10", int64(15)},
+		{"3 * 4", int64(12)},
+		{"25 ** 2", int64(625)},
+		{"(8 + 2) * 3", int64(30)},
 		{"81 / 9", 9.},
 		{"4 + 2.50", 6.5},
-		{"32 - 16", 16.},
+		{"32 - 16", int64(16)},
 		{"5 / 2", 2.5},
 		{"9 * 3.25", 29.25},
-		{"2 + 27", 29.},
-		{"16 ** 2", 256.},
-		{"10 - 5", 5.},
+		{"2 + 27", int64(29)},
+		{"16 ** 2", int64(256)},
+		{"10 - 5", int64(5)},
 		{"3 + 0.25", 3.25},
-		{"8 * 4", 32.},
-		{"64 + 9", 73.},
+		{"8 * 4", int64(32)},
+		{"64 + 9", int64(73)},
 		{"5 - 2.09", 2.91},
 		{"25 / 5", 5.},
-		{"2 * (3 + 4)", 14.},
-		{"81 + 3", 84.},
-		{"4 ** 3", 64.},
+		{"2 * (3 + 4)", int64(14)},
+		{"81 + 3", int64(84)},
+		{"4 ** 3", int64(64)},
 		{"9 - 2.50", 6.5},
 		{"32 / 8", 4.},
-		{"5 + 8", 13.},
+		{"5 + 8", int64(13)},
 		{"16 + 2.25", 18.25},
-		{"27 * 3", 81.},
+		{"27 * 3", int64(81)},
 		{"2 - 0.09", 1.91},
-		{"10 * 2", 20.},
-		{"3 ** 2", 9.},
-		{"64 - 4", 60.},
+		{"10 * 2", int64(20)},
+		{"3 ** 2", int64(9)},
+		{"64 - 4", int64(60)},
 		{"(5 + 3) / 2", 4.},
 		{"8 + 5.50", 13.5},
-		{"25 + 9", 34.},
+		{"25 + 9", int64(34)},
 		{"4 * 2.09", 8.36},
 		{"81 / 3", 27.},
-		{"2 + 16", 18.},
-		{"5 - 3", 2.},
-		{"32 * 4", 128.},
+		{"2 + 16", int64(18)},
+		{"5 - 3", int64(2)},
+		{"32 * 4", int64(128)},
 		{"9 + 2.25", 11.25},
 		{"27 / 9", 3.},
-		{"10 ** 3", 1000.},
-		{"3 * (8 + 2)", 30.},
-		{"16 - 5", 11.},
+		{"10 ** 3", int64(1000)},
+		{"3 * (8 + 2)", int64(30)},
+		{"16 - 5", int64(11)},
 		{"4 + 0.25", 4.25},
 		{"64 / 2", 32.},
 		{"5 + 2.50", 7.5},
-		{"8 * 3", 24.},
-		{"25 - 9", 16.},
-		{"2 ** 4", 16.},
-		{"81 + 5", 86.},
+		{"8 * 3", int64(24)},
+		{"25 - 9", int64(16)},
+		{"2 ** 4", int64(16)},
+		{"81 + 5", int64(86)},
 		{"3 - 0.09", 2.91},
 		{"32 / 4", 8.},
-		{"9 * 2", 18.},
-		{"16 + 8", 24.},
+		{"9 * 2", int64(18)},
+		{"16 + 8", int64(24)},
 		{"5 + 3.25", 8.25},
-		{"27 ** 2", 729.},
-		{"(4 + 2) * 5", 30.},
-		{"10 - 2", 8.},
-		{"64 * 3", 192.},
+		{"27 ** 2", int64(729)},
+		{"(4 + 2) * 5", int64(30)},
+		{"10 - 2", int64(8)},
+		{"64 * 3", int64(192)},
 		{"2 + 5.50", 7.5},
 		{"8 / 4", 2.},
-		{"25 + 4", 29.},
+		{"25 + 4", int64(29)},
 		{"3 * 2.09", 6.27},
-		{"81 - 9", 72.},
-		{"5 ** 3", 125.},
+		{"81 - 9", int64(72)},
+		{"5 ** 3", int64(125)},
 		{"16 / 2", 8.},
-		{"4 + 9", 13.},
-		{"32 - 2", 30.},
+		{"4 + 9", int64(13)},
+		{"32 - 2", int64(30)},
 		{"2 * 3.25", 6.5},
-		{"27 + 5", 32.},
+		{"27 + 5", int64(32)},
 		{"10 + 2.50", 12.5},
-		{"8 ** 2", 64.},
+		{"8 ** 2", int64(64)},
 		{"9 / 3", 3.},
-		{"64 + 3", 67.},
+		{"64 + 3", int64(67)},
 		{"5 - 0.25", 4.75},
-		{"25 * 2", 50.},
-		{"3 + 8", 11.},
-		{"16 - 4", 12.},
+		{"25 * 2", int64(50)},
+		{"3 + 8", int64(11)},
+		{"16 - 4", int64(12)},
 		{"2 + 2.09", 4.09},
 		{"81 / 27", 3.},
-		{"4 * 5", 20.},
-		{"32 + 9", 41.},
+		{"4 * 5", int64(20)},
+		{"32 + 9", int64(41)},
 		{"5 + 2.25", 7.25},
-		{"8 * (3 + 2)", 40.},
-		{"27 - 8", 19.},
-		{"10 ** 2", 100.},
+		{"8 * (3 + 2)", int64(40)},
+		{"27 - 8", int64(19)},
+		{"10 ** 2", int64(100)},
 		{"3 - 2.50", 0.5},
 		{"64 / 8", 8.},
-		{"9 + 4", 13.},
-		{"2 * 5", 10.},
+		{"9 + 4", int64(13)},
+		{"2 * 5", int64(10)},
 		{"25 / 5", 5.},
 		{"4 + 3.09", 7.09},
-		{"16 ** 3", 4096.},
-		{"5 + 8", 13.},
-		{"32 - 3", 29.},
+		{"16 ** 3", int64(4096)},
+		{"5 + 8", int64(13)},
+		{"32 - 3", int64(29)},
 		{"2 + 0.25", 2.25},
-		{"81 * 2", 162.},
+		{"81 * 2", int64(162)},
 		{"3 / 3", 1.},
 		{"8 + 2.50", 10.5},
-		{"27 + 4", 31.},
-		{"10 - 5", 5.},
-		{"64 * 2", 128.},
-		{"5 ** 2", 25.},
+		{"27 + 4", int64(31)},
+		{"10 - 5", int64(5)},
+		{"64 * 2", int64(128)},
+		{"5 ** 2", int64(25)},
 		{"9 - 2.09", 6.91},
-		{"25 + 3", 28.},
-		{"4 * (2 + 5)", 28.},
+		{"25 + 3", int64(28)},
+		{"4 * (2 + 5)", int64(28)},
 		{"16 / 4", 4.},
-		{"2 + 9", 11.},
+		{"2 + 9", int64(11)},
 		{"32 + 2.25", 34.25},
 		{"3 * 5.50", 16.5},
-		{"8 - 3", 5.},
+		{"8 - 3", int64(5)},
 		{"81 / 9", 9.},
 		{"5 + 2.09", 7.09},
-		{"-2 + 5", 3.},
-		{"-3 * 4", -12.},
+		{"-2 + 5", int64(3)},
+		{"-3 * 4", int64(-12)},
 		{"-8 / 2", -4.},
 		{"-16 + 3.25", -12.75},
-		{"-9 ** 2", -81.},
-		{"-(-5 + 2)", 3.},
+		{"-9 ** 2", int64(-81)},
+		{"-(-5 + 2)", int64(3)},
 		{"-4 - 2.09", -6.09},
 		{"-27 / 3", -9.},
-		{"-10 * 8", -80.},
+		{"-10 * 8", int64(-80)},
 		{"-32 + 5.50", -26.5},
-		{"-2 ** 3", -8.},
-		{"-(-9 + 4)", 5.},
+		{"-2 ** 3", int64(-8)},
+		{"-(-9 + 4)", int64(5)},
 		{"-25 / 5", -5.},
 		{"-3 * 2.25", -6.75},
-		{"-64 - 16", -80.},
-		{"-8 + 3", -5.},
-		{"-5 ** 2", -25.},
+		{"-64 - 16", int64(-80)},
+		{"-8 + 3", int64(-5)},
+		{"-5 ** 2", int64(-25)},
 		{"-16 / 4", -4.},
-		{"-9 * (-2)", 18.},
-		{"-81 + 5", -76.},
+		{"-9 * (-2)", int64(18)},
+		{"-81 + 5", int64(-76)},
 		{"-4 - 0.25", -4.25},
 		{"-27 / 9", -3.},
 		{"-10 + 2.09", -7.91},
-		{"-3 * 8", -24.},
-		{"-32 ** 2", -1024.},
-		{"-(-5 + 3)", 2.},
-		{"-2 + 9", 7.},
+		{"-3 * 8", int64(-24)},
+		{"-32 ** 2", int64(-1024)},
+		{"-(-5 + 3)", int64(2)},
+		{"-2 + 9", int64(7)},
 		{"-8 * 4.50", -36.},
 		{"-64 / 2", -32.},
-		{"-25 - 3", -28.},
+		{"-25 - 3", int64(-28)},
 		{"-9 + 2.25", -6.75},
-		{"-16 * (-2)", 32.},
+		{"-16 * (-2)", int64(32)},
 		{"-5 / 1", -5.},
-		{"-3 ** 3", -27.},
-		{"-81 - 4", -85.},
+		{"-3 ** 3", int64(-27)},
+		{"-81 - 4", int64(-85)},
 		{"-2 + 5.50", 3.5},
-		{"-10 * 3", -30.},
-		{"-27 + 8", -19.},
-		{"-4 ** 2", -16.},
+		{"-10 * 3", int64(-30)},
+		{"-27 + 8", int64(-19)},
+		{"-4 ** 2", int64(-16)},
 		{"-32 / 8", -4.},
 		{"-9 - 2.09", -11.09},
-		{"-5 * (-3)", 15.},
-		{"-16 + 4", -12.},
+		{"-5 * (-3)", int64(15)},
+		{"-16 + 4", int64(-12)},
 		{"-8 / 2", -4.},
 		{"-3 + 2.25", -0.75},
-		{"-64 * 2", -128.},
-		{"-25 ** 2", -625.},
-		{"-(-9 + 5)", 4.},
+		{"-64 * 2", int64(-128)},
+		{"-25 ** 2", int64(-625)},
+		{"-(-9 + 5)", int64(4)},
 		{"-2 - 0.09", -2.09},
 		{"-81 / 3", -27.},
-		{"(2 + 3) * 5 - 4", 21.},
+		{"(2 + 3) * 5 - 4", int64(21)},
 		{"8 * (2 + 3.25) / 2", 21.},
-		{"-9 ** 2 + 5", -76.},
+		{"-9 ** 2 + 5", int64(-76)},
 		{"(16 - 4) / 3 * 2", 8.},
 		{"5 * (3 + 2.09) - 8", 17.45},
 		{"64 / (2 + 3) + 1", 13.8},
 		{"-4 + 9 * 2.25", 16.25},
 		{"27 / (3 - 1) * 5", 67.5},
-		{"10 + (8 - 3) * 2", 20.},
+		{"10 + (8 - 3) * 2", int64(20)},
 		{"-32 * 2 / 5 + 4", -8.8},
-		{"2 ** 3 + 5 - 1", 12.},
+		{"2 ** 3 + 5 - 1", int64(12)},
 		{"(9 + 4) / 2 * 3", 19.5},
 		{"-25 * (2 - 0.25)", -43.75},
 		{"3 * 8 + 5.50 - 2", 27.5},
 		{"-64 / (4 + 4) * 2", -16.},
-		{"8 + (5 - 2) * 3", 17.},
+		{"8 + (5 - 2) * 3", int64(17)},
 		{"-5 ** 2 + 9 / 3", -22.},
-		{"(16 + 2) * 3 - 4", 50.},
+		{"(16 + 2) * 3 - 4", int64(50)},
 		{"-9 * (2 + 0.09) + 5", -13.809999999999999},
 		{"81 / (3 * 3) + 2", 11.},
 		{"-4 + 2.50 * 5 - 1", 7.5},
 		{"27 - (9 / 3) * 2", 21.},
 		{"-10 + 8 * 2.25", 8.},
-		{"3 ** 2 + 5 * 2", 19.},
+		{"3 ** 2 + 5 * 2", int64(19)},
 		{"-32 / 4 + 8 - 2", -2.},
 		{"(5 + 3) * 2 - 0.25", 15.75},
 		{"-8 * (4 + 1) / 5", -8.},
-		{"64 + (3 - 1) * 2", 68.},
+		{"64 + (3 - 1) * 2", int64(68)},
 		{"-9 + 5.25 * 2 - 3", -1.5},
 		{"(16 / 2) * 3 + 1", 25.},
-		{"-2 ** 3 + 9 - 4", -3.},
+		{"-2 ** 3 + 9 - 4", int64(-3)},
 		{"25 * (2 + 0.50) - 5", 57.5},
 		{"-3 + (8 / 2) * 4", 13.},
-		{"81 - 9 * 2 + 3", 66.},
-		{"-4 ** 2 + 5 * 2", -6.},
+		{"81 - 9 * 2 + 3", int64(66)},
+		{"-4 ** 2 + 5 * 2", int64(-6)},
 		{"(32 / 8) + 3 * 2", 10.},
 		{"-5 * (2 + 2.09) - 1", -21.45},
-		{"16 + 4 * (3 - 1)", 24.},
+		{"16 + 4 * (3 - 1)", int64(24)},
 		{"-8 / 2 + 5 ** 2", 21.},
 		{"-9 + (3 * 2.25) - 1", -3.25},
-		{"64 * (2 - 1) + 3", 67.},
+		{"64 * (2 - 1) + 3", int64(67)},
 		{"-25 / 5 + 4 * 2", 3.},
 		{"(3 + 2) * 5 - 0.09", 24.91},
 		{"-2 * (8 + 4) / 3", -8.},
 		{"81 / (9 - 3) + 5", 18.5},
-		{"-10 * 2 + 3 ** 2", -11.},
+		{"-10 * 2 + 3 ** 2", int64(-11)},
 		{"4 + (5 * 2) - 3.25", 10.75},
-		{"-32 + 8 * (2 + 1)", -8.},
+		{"-32 + 8 * (2 + 1)", int64(-8)},
 		{"(9 / 3) * 2 + 5", 11.},
 		{"-5 ** 2 + 16 / 4", -21.},
-		{"(2 + 3) * (5 - 1) * 2", 40.},
-		{"-8 * 2 + 3 ** 2 - 1", -8.},
+		{"(2 + 3) * (5 - 1) * 2", int64(40)},
+		{"-8 * 2 + 3 ** 2 - 1", int64(-8)},
 		{"64 / (4 + 4) * 3 + 2", 26.},
 		{"-9 + (5 * 2.50) - 3", 0.5},
 		{"(16 - 2) / 2 * 5 - 1", 34.},
 		{"-4 * (3 + 2.09) + 8", -12.36},
 		{"27 + (9 / 3) * 2 - 4", 29.},
-		{"-10 ** 2 + 5 * 3", -85.},
+		{"-10 ** 2 + 5 * 3", int64(-85)},
 		{"(32 + 8) / 4 * 2", 20.},
 		{"-3 * (5 + 2.25) - 1", -22.75},
 		{"81 - (9 * 2) + 3.50", 66.5},
 		{"-2 + 4 * 5 - 0.09", 17.91},
 		{"(8 + 2) * 3 / 2 + 1", 16.},
 		{"-64 / (8 - 4) * 2", -32.},
-		{"5 * (3 ** 2) - 8 + 2", 39.},
+		{"5 * (3 ** 2) - 8 + 2", int64(39)},
 		{"-16 + 4 * (2 + 0.25)", -7.},
 		{"9 * (2 - 0.50) + 3", 16.5},
 		{"-25 / 5 + 8 * 2 - 1", 10.},
-		{"(3 + 5) * 2 - 4 ** 2", 0.},
+		{"(3 + 5) * 2 - 4 ** 2", int64(0)},
 		{"-4 * (8 / 2) + 5.25", -10.75},
 		{"32 + (9 - 3) * 2 / 1", 44.},
-		{"-2 ** 3 + 5 * 3 - 2", 5.},
+		{"-2 ** 3 + 5 * 3 - 2", int64(5)},
 		{"(81 / 9) - 2 * 2.09", 4.82},
-		{"-10 + (4 + 3) * 2", 4.},
+		{"-10 + (4 + 3) * 2", int64(4)},
 		{"64 * (2 - 1) + 5 - 0.25", 68.75},
 		{"-8 / (2 + 2) * 3 + 1", -5.},
-		{"(5 * 3) + 2 ** 2 - 4", 15.},
+		{"(5 * 3) + 2 ** 2 - 4", int64(15)},
 		{"-9 * (2 + 0.09) + 3 * 2", -12.809999999999999},
 		{"27 / (3 * 1) + 5 - 2", 12.},
 		{"-4 + 8 * 2.50 - 3", 13.},
@@ -352,52 +352,52 @@ func Test_Calc(t *testing.T) {
 		{"-64 / 4 + 8 * 2 - 3", -3.},
 		{"(9 + 3) * 2 / 1 + 5", 29.},
 		{"-5 * (2 + 2.25) - 8 + 1", -28.25},
-		{"25 + (4 * 2) - 3 ** 2", 24.},
+		{"25 + (4 * 2) - 3 ** 2", int64(24)},
 		{"-2 * (8 + 4) / 2 + 5", -7.},
 		{"81 / (9 - 3) * 2 + 1", 28.},
 		{"-10 + 3 * 5 - 2.50", 2.5},
 		{"(4 + 2) * 3 - 8 / 2", 14.},
-		{"-16 ** 2 + 5 * 3 - 4", -245.},
+		{"-16 ** 2 + 5 * 3 - 4", int64(-245)},
 		{"64 / (2 + 2) + 3 * 2", 22.},
-		{"-9 * (5 - 2) + 4 ** 2", -11.},
+		{"-9 * (5 - 2) + 4 ** 2", int64(-11)},
 		{"(32 - 8) / 3 * 2 + 1", 17.},
 		{"-5 + (2 * 3.25) - 8", -6.5},
 		{"27 + 9 * (2 - 0.25) - 3", 39.75},
-		{"-4 ** 2 + 5 * (2 + 1)", -1.},
-		{"-2 ** 3 ** 2", -512.},
-		{"-(-3 ** 2) ** 2", -81.},
-		{"-5 ** 2 ** 2", -625.},
-		{"-(-9 ** 2) ** 2", -6561.},
-		{"-4 ** 3 ** 2", -262144.},
-		{"-8 ** 2 ** 2", -4096.},
-		{"-(-16 ** 2) ** 2", -65536.},
-		{"-25 ** 2 ** 2", -390625.},
-		{"-(-10 ** 2) ** 3", 1000000.},
-		{"-3 ** 3 ** 2", -19683.},
-		{"-(-2 ** 4) ** 2", -256.},
-		{"-64 ** 2 ** 2", -16777216.},
-		{"-(-5 ** 3) ** 2", -15625.},
-		{"-9 ** 2 ** 3", -43046721.},
-		{"-(-4 ** 2) ** 3", 4096.},
-		{"-27 ** 2 ** 2", -531441.},
-		{"-(-8 ** 3) ** 2", -262144.},
+		{"-4 ** 2 + 5 * (2 + 1)", int64(-1)},
+		{"-2 ** 3 ** 2", int64(-512)},
+		{"-(-3 ** 2) ** 2", int64(-81)},
+		{"-5 ** 2 ** 2", int64(-625)},
+		{"-(-9 ** 2) ** 2", int64(-6561)},
+		{"-4 ** 3 ** 2", int64(-262144)},
+		{"-8 ** 2 ** 2", int64(-4096)},
+		{"-(-16 ** 2) ** 2", int64(-65536)},
+		{"-25 ** 2 ** 2", int64(-390625)},
+		{"-(-10 ** 2) ** 3", int64(1000000)},
+		{"-3 ** 3 ** 2", int64(-19683)},
+		{"-(-2 ** 4) ** 2", int64(-256)},
+		{"-64 ** 2 ** 2", int64(-16777216)},
+		{"-(-5 ** 3) ** 2", int64(-15625)},
+		{"-9 ** 2 ** 3", int64(-43046721)},
+		{"-(-4 ** 2) ** 3", int64(4096)},
+		{"-27 ** 2 ** 2", int64(-531441)},
+		{"-(-8 ** 3) ** 2", int64(-262144)},
 		{"-2.25 ** 2 ** 2", -25.62890625},
 		{"-(-3.09 ** 2) ** 2", -91.16621361},
 		{"-5.50 ** 2 ** 2", -915.0625},
-		{"-(-16 ** 3) ** 2", -16777216.},
-		{"-10 ** 3 ** 2", -1000000000.},
-		{"-(-2 ** 2) ** 3", 64.},
-		{"-81 ** 2 ** 2", -43046721.},
-		{"-(-4 ** 3) ** 2", -4096.},
-		{"-((2 ** 2) * 3 + 5)", -17.},
+		{"-(-16 ** 3) ** 2", int64(-16777216)},
+		{"-10 ** 3 ** 2", int64(-1000000000)},
+		{"-(-2 ** 2) ** 3", int64(64)},
+		{"-81 ** 2 ** 2", int64(-43046721)},
+		{"-(-4 ** 3) ** 2", int64(-4096)},
+		{"-((2 ** 2) * 3 + 5)", int64(-17)},
 		{"-(3 ** 2 / (4 - 1))", -3.},
-		{"-((5 * 2) ** 2 - 8)", -92.},
+		{"-((5 * 2) ** 2 - 8)", int64(-92)},
 		{"-(9 ** 2 / (3 + 2.25))", -15.428571428571429},
-		{"-((4 + 8) * 2 ** 2)", -48.},
-		{"-((-8 ** 2) + 5 * 3)", 49.},
+		{"-((4 + 8) * 2 ** 2)", int64(-48)},
+		{"-((-8 ** 2) + 5 * 3)", int64(49)},
 		{"-((16 / 4) ** 2 - 2.09)", -13.91},
 		{"-((-25 * 2) ** 2 / 5)", -500.},
-		{"-((10 ** 2 - 3) * 2)", -194.},
+		{"-((10 ** 2 - 3) * 2)", int64(-194)},
 		{"-((-3 ** 3) + 4 / 2)", 25.},
 		{"-((2 ** 2 * 5) - 0.25)", -19.75},
 		{"-((-64 / 8) ** 2 + 3)", -67.},
@@ -411,7 +411,7 @@ func Test_Calc(t *testing.T) {
 		{"-((-5.50 + 3) * 2 ** 2)", 10.},
 		{"-((16 ** 2 / 8) - 5)", -27.},
 		{"-((-10 * 2) ** 2 + 3.25)", -403.25},
-		{"-((2 ** 3 - 1) * 4)", -28.},
+		{"-((2 ** 3 - 1) * 4)", int64(-28)},
 		{"-((-81 / 9) ** 2 + 2)", -83.},
 		{"-((4 ** 2 * 3) - 5.50)", -42.5},
 		{"-((2 ** 3) / (4 - 3.75) * 5)", -160.},
@@ -422,7 +422,7 @@ func Test_Calc(t *testing.T) {
 		{"-((-8 ** 2) + (5 * 3) - 0.25)", 49.25},
 		{"-((16 / (4 + 4)) ** 3 * 2)", -16.},
 		{"-((-25 * 2) ** 2 / (5 + 5))", -250.},
-		{"-((10 ** 2 - 3) * (2 ** 1))", -194.},
+		{"-((10 ** 2 - 3) * (2 ** 1))", int64(-194)},
 		{"-((-3 ** 3) + (4 / 2.25) * 5)", 18.11111111111111},
 		{"-((2 ** 2 * 5) - (8 / 2.09))", -16.17224880382775},
 		{"-((-64 / 8) ** 2 + (3 * 2))", -70.},
@@ -472,7 +472,7 @@ func Test_Calc(t *testing.T) {
 		{"-((5 ** 2 - 3) * (2.09 / 1))", -45.98},
 		{"-((64 / (4 + 4)) ** 3 - 2)", -510.},
 		{"-((-9 ** 2) / (3 + 0.09) * 2)", 52.42718446601942},
-		{"-((4 + 3) * (2 ** 3) - 5)", -51.},
+		{"-((4 + 3) * (2 ** 3) - 5)", int64(-51)},
 		{"-((-27 / (3 + 0.50)) * (2 ** 2))", 30.857142857142858},
 		{"-((8 ** 2 - 3) / (2.25 * 3))", -9.037037037037036},
 		{"-((-2 ** 3) + (5 * 2.50) - 3)", -1.5},
@@ -499,7 +499,7 @@ func Test_Calc(t *testing.T) {
 		{"-((5 ** 2 - 4) * (2.25 / 1))", -47.25},
 		{"-((64 / (8 - 4)) ** 3 - 3)", -4093.},
 		{"-((-9 ** 2) / (2 + 0.25) * 4)", 144.},
-		{"-((4 + 2) * (2 ** 4) - 5)", -91.},
+		{"-((4 + 2) * (2 ** 4) - 5)", int64(-91)},
 		{"-((-27 / (3 + 0.25)) * (2 ** 3))", 66.46153846153847},
 		{"-((8 ** 2 - 2) / (3.09 * 2))", -10.032362459546926},
 		{"-((-2 ** 4) + (5 * 2.50) - 4)", 7.5},
@@ -721,10 +721,10 @@ func Test_Calc(t *testing.T) {
 		{"1 == 1 && (1 != 1 || 1 == 1)", true},
 		{"1 == 1 && (1 != 1 && 1 == 1)", false},
 		{"1 == 1 || (1 != 1 && 1 == 1)", true},
-		{"5 > 3 ? 16 : 9", 16.},
-		{"0 > 16 ? 25 : 9 > 5 ? 15 : 2", 15.},
-		{"20 >= 27 ? 81 : 8 >= 4 ? 2 : 3", 2.},
-		{"16 > 9 ? 32 : (8 > 3 ? (25 >= 15 ? 4 : 81) : (27 < 64 ? (5 > 2 ? 20 : 2) : 3))", 32.},
+		{"5 > 3 ? 16 : 9", int64(16)},
+		{"0 > 16 ? 25 : 9 > 5 ? 15 : 2", int64(15)},
+		{"20 >= 27 ? 81 : 8 >= 4 ? 2 : 3", int64(2)},
+		{"16 > 9 ? 32 : (8 > 3 ? (25 >= 15 ? 4 : 81) : (27 < 64 ? (5 > 2 ? 20 : 2) : 3))", int64(32)},
 		{`16 > 9 ? "16 > 9" : "16 <= 9"`, `16 > 9`},
 		{`"привет " + 'мир'`, `привет мир`},
 		{`name == "tyson" ? "привет tyson" : "кто ты?"`, "привет tyson"},
@@ -741,11 +741,48 @@ func Test_Calc(t *testing.T) {
 		{`"привет" >= 'мир'`, true},
 		{`"привет" > 'привет'`, false},
 		{`"привет" >= 'привет'`, true},
-		{"age + age", 64.},
-		{"age - age", 0.},
-		{"age * 2", 64.},
+		{"age + age", int64(64)},
+		{"age - age", int64(0)},
+		{"age * 2", int64(64)},
 		{"age / age", 1.},
-		{"age ** 2", 1024.},
+		{"age ** 2", int64(1024)},
+		{"- -5", int64(5)},
+		{"-(-5)", int64(5)},
+		{"-+-5", int64(5)},
+		{"+age", int64(32)},
+		{"-2 ** 2", int64(-4)},
+		{"2 - -2", int64(4)},
+		{"!is_admin", false},
+		{"!!is_admin", true},
+		{"!(age > 18) || is_admin", true},
+		{"!is_admin == is_admin", false},
+		{"7 % 3", int64(1)},
+		{"-7 % 3", int64(2)},
+		{"7 % -3", int64(-2)},
+		{"-7 % -3", int64(-1)},
+		{"7.5 % 2", 1.5},
+		{"7 // 2", int64(3)},
+		{"-7 // 2", int64(-4)},
+		{"-7 // 2 * 2 + -7 % 2", int64(-7)},
+		{"age % 3 == 2", true},
+		{"2 + 10 % 4 * 3", int64(8)},
+		{"mod(-7, 3)", int64(2)},
+		{"div(-7, 2)", int64(-4)},
+		{"rem(-7, 3)", int64(-1)},
+		{"6 & 3", int64(2)},
+		{"6 | 3", int64(7)},
+		{"6 ^ 3", int64(5)},
+		{"~5", int64(-6)},
+		{"~-1", int64(0)},
+		{"1 << 4", int64(16)},
+		{"256 >> 4", int64(16)},
+		{"-16 >> 2", int64(-4)},
+		{"1 << 2 + 1", int64(8)},
+		{"1 | 2 ^ 3 & 4", int64(3)},
+		{"(1 | 2) ^ 3", int64(0)},
+		{"age & 31 == 0", true},
+		{"age | 1 > age", true},
+		{"6 & 3 == 2 && 6 | 3 == 7", true},
 	}
 
 	for _, test := range tests {
@@ -761,3 +798,47 @@ func Test_Calc(t *testing.T) {
 select expr, expr, ... from " http | file " where expr(bool) and expr(bool) ... group by ident, ident
 having (group by field) | (agg(ident) order by ident, ident desc | asc limit n offset n;
 */
+
+func Test_Calc_in(t *testing.T) {
+	ns := namespace{
+		"role":  "editor",
+		"roles": []string{"admin", "editor"},
+		"user":  map[string]any{"name": "tyson", "age": 32},
+		"codes": map[int]string{404: "not found"},
+		"item":  Product{Title: "чайник"},
+	}
+
+	tests := []struct {
+		program  string
+		expected any
+	}{
+		{`role in ["admin", "editor", "owner"]`, true},
+		{`role in roles`, true},
+		{`"guest" in roles`, false},
+		{`"guest" not in roles`, true},
+		{`role not in roles`, false},
+		{`2 in [1, 2, 3]`, true},
+		{`[1, 2] in [[1, 2], [3]]`, true},
+		{`"2" in [1, 2, 3]`, false},
+		{`1 in []`, false},
+		{`"name" in user`, true},
+		{`"email" in user`, false},
+		{`"email" not in user`, true},
+		{`404 in codes`, true},
+		{`500 in codes`, false},
+		{`"title" in item && "Secret" not in item`, true},
+		{`"dit" in role`, true},
+		{`"DIT" in role`, false},
+		{`"" in role`, true},
+		{`(1 + 1 in [2]) == (3 not in [2])`, true},
+		{`role in roles && "admin" in roles || role in []`, true},
+		{`len(filter(roles, r => r not in ["admin"])) == 1`, true},
+	}
+
+	for _, test := range tests {
+		val := Calc(test.program, ns)
+		if !reflect.DeepEqual(val, test.expected) {
+			t.Errorf("%s: got %v, want %v", test.program, val, test.expected)
+		}
+	}
+}
@@ -0,0 +1,613 @@
+package calc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+/*
+Check проверяет выражение без выполнения: выводит тип каждого узла по типам
+идентификаторов из Schema и возвращает первую ошибку с позицией — те же ошибки,
+что вернул бы Eval, например несовпадение типов в name + 1 или age && true.
+правила повторяют выполнение: целые числа остаются Int в +, -, *, //, %,
+деление / даёт Float, а значение типа Any проходит любые проверки.
+*/
+
+// Schema задаёт типы идентификаторов и функций для Check.
+type Schema map[string]*Type
+
+// Check разбирает выражение и проверяет типы по schema.
+// возвращает тип результата выражения.
+func Check(src string, schema Schema) (*Type, error) {
+	p, err := Compile(src)
+	if err != nil {
+		return nil, err
+	}
+	return p.Check(schema)
+}
+
+// Check проверяет типы разобранной программы по schema.
+func (p *Program) Check(schema Schema) (*Type, error) {
+	c := &checker{schema: schema}
+	return c.check(p.root, nil)
+}
+
+type checker struct {
+	schema Schema
+}
+
+// typeScope — переменная из let или параметр лямбды поверх родительской области.
+type typeScope struct {
+	parent *typeScope
+	name   string
+	typ    *Type
+}
+
+func (c *checker) lookup(s *typeScope, name string) (*Type, bool) {
+	for ; s != nil; s = s.parent {
+		if s.name == name {
+			return s.typ, true
+		}
+	}
+
+	typ, ok := c.schema[name]
+	if !ok || typ == nil {
+		return nil, false
+	}
+	return typ, true
+}
+
+func (c *checker) check(n node, s *typeScope) (*Type, error) {
+	switch n := n.(type) {
+	case *numNode:
+		return TypeFloat(), nil
+	case *intNode:
+		return TypeInt(), nil
+	case *decNode:
+		return TypeNumber(), nil
+	case *strNode:
+		return TypeString(), nil
+	case *nullNode:
+		return TypeNull(), nil
+	case *constNode:
+		return TypeOf(n.val), nil
+	case *errNode:
+		return nil, n.err
+
+	case *identNode:
+		typ, ok := c.lookup(s, n.val)
+		if !ok {
+			return nil, &UnknownIdentifierError{Code: CodeUnknownIdentifier, Pos: n.pos, Name: n.val}
+		}
+		return typ, nil
+
+	case *unaryNode:
+		val, err := c.check(n.val, s)
+		if err != nil {
+			return nil, err
+		}
+		return c.unary(n, val)
+
+	case *binaryNode:
+		left, err := c.check(n.left, s)
+		if err != nil {
+			return nil, err
+		}
+		right, err := c.check(n.right, s)
+		if err != nil {
+			return nil, err
+		}
+		return c.binary(n, left, right)
+
+	case *matchNode:
+		return c.match(n, s)
+
+	case *ternaryNode:
+		cond, err := c.check(n.cond, s)
+		if err != nil {
+			return nil, err
+		}
+		if cond.kind != KindBool && cond.kind != KindAny {
+			return nil, typeError(CodeInvalidOperand, n.pos, "?:", cond)
+		}
+
+		ifTrue, err := c.check(n.ifTrue, s)
+		if err != nil {
+			return nil, err
+		}
+		ifFalse, err := c.check(n.ifFalse, s)
+		if err != nil {
+			return nil, err
+		}
+		return unify(ifTrue, ifFalse), nil
+
+	case *coalesceNode:
+		left, err := c.check(n.left, s)
+		if err != nil {
+			if !isMissing(n.left, err) {
+				return nil, err
+			}
+			left = TypeNull()
+		}
+
+		right, err := c.check(n.right, s)
+		if err != nil {
+			return nil, err
+		}
+		return unify(left, right), nil
+
+	case *listNode:
+		elem := TypeNull()
+		for _, item := range n.items {
+			typ, err := c.check(item, s)
+			if err != nil {
+				return nil, err
+			}
+			elem = unify(elem, typ)
+		}
+		if elem.kind == KindNull {
+			elem = TypeAny()
+		}
+		return ListOf(elem), nil
+
+	case *mapNode:
+		fields := make(map[string]*Type, len(n.keys))
+		for i, key := range n.keys {
+			typ, err := c.check(n.vals[i], s)
+			if err != nil {
+				return nil, err
+			}
+			fields[key] = typ
+		}
+		return ObjectOf(fields), nil
+
+	case *memberNode:
+		val, err := c.check(n.val, s)
+		if err != nil {
+			return nil, err
+		}
+		return c.member(n, val)
+
+	case *indexNode:
+		return c.index(n, s)
+
+	case *sliceNode:
+		return c.slice(n, s)
+
+	case *callNode:
+		return c.call(n, s)
+
+	case *lambdaNode:
+		return c.lambda(n, nil, s)
+
+	case *letNode:
+		val, err := c.check(n.val, s)
+		if err != nil {
+			return nil, err
+		}
+		return c.check(n.body, &typeScope{s, n.name, val})
+
+	case *seqNode:
+		if _, err := c.check(n.first, s); err != nil {
+			return nil, err
+		}
+		return c.check(n.rest, s)
+
+	default:
+		return nil, fmt.Errorf("calc: Check: неизвестный узел %T", n)
+	}
+}
+
+func (c *checker) unary(n *unaryNode, val *Type) (*Type, error) {
+	if val.kind == KindAny {
+		return TypeAny(), nil
+	}
+
+	switch n.op {
+	case addOp, subOp, bitNotOp:
+		if val.numeric() {
+			return val, nil
+		}
+	case notOp:
+		if val.kind == KindBool {
+			return TypeBool(), nil
+		}
+	}
+	return nil, typeError(CodeInvalidOperand, n.pos, opName(n.op), val)
+}
+
+// binary проверяет операнды в том же порядке, что и binaryNode.exec.
+func (c *checker) binary(n *binaryNode, left, right *Type) (*Type, error) {
+	op := opName(n.op)
+
+	switch n.op {
+	case inOp, notInOp:
+		switch right.kind {
+		case KindAny, KindList:
+			return TypeBool(), nil
+		case KindString:
+			if left.kind == KindString || left.kind == KindAny {
+				return TypeBool(), nil
+			}
+		case KindObject:
+			if assignable(right.keyType(), left) {
+				return TypeBool(), nil
+			}
+		}
+		return nil, typeError(CodeInvalidOperand, n.pos, op, left, right)
+
+	case andOp, orOp:
+		for _, operand := range []*Type{left, right} {
+			if operand.kind != KindBool && operand.kind != KindAny {
+				return nil, typeError(CodeInvalidOperand, n.pos, op, operand)
+			}
+		}
+		return TypeBool(), nil
+	}
+
+	comparison := n.op >= eqOp && n.op <= moreEqOp
+
+	if left.kind == KindAny || right.kind == KindAny {
+		if comparison {
+			return TypeBool(), nil
+		}
+		return TypeAny(), nil
+	}
+
+	if (n.op == eqOp || n.op == notEqOp) && (left.kind == KindNull || right.kind == KindNull) {
+		return TypeBool(), nil
+	}
+
+	if left.numeric() && right.numeric() {
+		switch {
+		case comparison:
+			return TypeBool(), nil
+		case n.op == divOp:
+			if left.kind == KindNumber || right.kind == KindNumber {
+				return TypeNumber(), nil
+			}
+			return TypeFloat(), nil
+		case n.op == powOp:
+			//целое в отрицательной степени даёт float64
+			if left.kind == KindFloat || right.kind == KindFloat {
+				return TypeFloat(), nil
+			}
+			return TypeNumber(), nil
+		default:
+			return arithmetic(left, right), nil
+		}
+	}
+
+	if left.kind != right.kind {
+		return nil, typeError(CodeMismatchedTypes, n.pos, op, left, right)
+	}
+
+	switch n.op {
+	case eqOp, notEqOp:
+		if left.kind != KindFunc {
+			return TypeBool(), nil
+		}
+	case lessOp, lessEqOp, moreOp, moreEqOp:
+		if left.kind == KindString {
+			return TypeBool(), nil
+		}
+	case addOp:
+		if left.kind == KindString {
+			return TypeString(), nil
+		}
+	}
+	return nil, typeError(CodeInvalidOperand, n.pos, op, left, right)
+}
+
+func (c *checker) match(n *matchNode, s *typeScope) (*Type, error) {
+	val, err := c.check(n.val, s)
+	if err != nil {
+		return nil, err
+	}
+
+	op := "=~"
+	if n.negate {
+		op = "!~"
+	}
+
+	if n.re == nil {
+		pattern, err := c.check(n.pattern, s)
+		if err != nil {
+			return nil, err
+		}
+		if pattern.kind != KindString && pattern.kind != KindAny {
+			return nil, typeError(CodeInvalidOperand, n.pos, op, val, pattern)
+		}
+	}
+
+	if val.kind != KindString && val.kind != KindAny {
+		return nil, typeError(CodeInvalidOperand, n.pos, op, val)
+	}
+	return TypeBool(), nil
+}
+
+func (c *checker) member(n *memberNode, val *Type) (*Type, error) {
+	switch val.kind {
+	case KindAny:
+		return TypeAny(), nil
+	case KindNull:
+		if n.optional {
+			return TypeNull(), nil
+		}
+	case KindObject:
+		if typ, ok := val.Field(n.name); ok {
+			return typ, nil
+		}
+		if n.optional {
+			return TypeNull(), nil
+		}
+		return nil, &MemberError{Code: CodeUnknownMember, Pos: n.pos, Path: n.path, Name: n.name}
+	}
+	return nil, typeError(CodeInvalidOperand, n.pos, ".", val)
+}
+
+func (c *checker) index(n *indexNode, s *typeScope) (*Type, error) {
+	val, err := c.check(n.val, s)
+	if err != nil {
+		return nil, err
+	}
+	index, err := c.check(n.index, s)
+	if err != nil {
+		return nil, err
+	}
+
+	switch val.kind {
+	case KindAny:
+		return TypeAny(), nil
+
+	case KindObject:
+		if !assignable(val.keyType(), index) {
+			return nil, typeError(CodeInvalidOperand, n.pos, "[]", val, index)
+		}
+		key, ok := n.index.(*strNode)
+		if !ok {
+			//ключ известен только при выполнении
+			return TypeAny(), nil
+		}
+		if typ, ok := val.Field(key.val); ok {
+			return typ, nil
+		}
+		return nil, &MemberError{Code: CodeUnknownMember, Pos: n.pos, Path: memberPath(n.val), Name: key.val}
+
+	case KindList, KindString:
+		if !index.numeric() && index.kind != KindAny {
+			return nil, typeError(CodeInvalidOperand, n.pos, "[]", index)
+		}
+		if val.kind == KindString {
+			return TypeString(), nil
+		}
+		return val.elem, nil
+	}
+	return nil, typeError(CodeInvalidOperand, n.pos, "[]", val)
+}
+
+func (c *checker) slice(n *sliceNode, s *typeScope) (*Type, error) {
+	val, err := c.check(n.val, s)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range []node{n.from, n.to} {
+		if b == nil {
+			continue
+		}
+		typ, err := c.check(b, s)
+		if err != nil {
+			return nil, err
+		}
+		if !typ.numeric() && typ.kind != KindAny {
+			return nil, typeError(CodeInvalidOperand, n.pos, "[:]", typ)
+		}
+	}
+
+	if val.kind != KindList && val.kind != KindString && val.kind != KindAny {
+		return nil, typeError(CodeInvalidOperand, n.pos, "[:]", val)
+	}
+	return val, nil
+}
+
+// lambda проверяет тело лямбды с типами параметров params, недостающие считаются Any.
+func (c *checker) lambda(n *lambdaNode, params []*Type, s *typeScope) (*Type, error) {
+	typs := make([]*Type, len(n.params))
+	for i, name := range n.params {
+		typs[i] = TypeAny()
+		if i < len(params) {
+			typs[i] = params[i]
+		}
+		s = &typeScope{s, name, typs[i]}
+	}
+
+	result, err := c.check(n.body, s)
+	if err != nil {
+		return nil, err
+	}
+	return FuncOf(result, typs...), nil
+}
+
+// call ищет функцию в том же порядке, что и callNode.lookup.
+func (c *checker) call(n *callNode, s *typeScope) (*Type, error) {
+	typ, found := c.lookup(s, n.name)
+	found = found && typ.kind != KindNull
+
+	switch {
+	case found && typ.kind == KindAny:
+		for _, arg := range n.args {
+			if _, err := c.check(arg, s); err != nil {
+				return nil, err
+			}
+		}
+		return TypeAny(), nil
+	case found && typ.kind == KindFunc:
+		return c.callFunc(n, typ, s)
+	}
+
+	if f, ok := builtins[n.name]; ok {
+		return c.callBuiltin(n, f, s)
+	}
+
+	if found {
+		return nil, &CallError{
+			Code: CodeNotCallable,
+			Pos:  n.pos,
+			Func: n.name,
+			Arg:  -1,
+			Msg:  "значение типа " + typ.String() + " не является функцией",
+		}
+	}
+
+	return nil, &UnknownIdentifierError{Code: CodeUnknownFunction, Pos: n.pos, Name: n.name}
+}
+
+// callFunc проверяет вызов лямбды из let или функции из Schema.
+func (c *checker) callFunc(n *callNode, fn *Type, s *typeScope) (*Type, error) {
+	args := make([]*Type, len(n.args))
+	for i, arg := range n.args {
+		typ, err := c.check(arg, s)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = typ
+	}
+
+	//параметры неизвестны, например у функции Go из TypeOf
+	if fn.params == nil {
+		return fn.result, nil
+	}
+
+	if len(args) != len(fn.params) {
+		return nil, &CallError{
+			Code: CodeArgumentCount,
+			Pos:  n.pos,
+			Func: n.name,
+			Arg:  -1,
+			Msg:  fmt.Sprintf("ожидалось %d аргументов, получено %d", len(fn.params), len(args)),
+		}
+	}
+
+	for i, param := range fn.params {
+		if !assignable(param, args[i]) {
+			return nil, argumentError(n, i, param.String(), args[i])
+		}
+	}
+
+	return fn.result, nil
+}
+
+// callBuiltin проверяет вызов встроенной функции по её сигнатуре в Go.
+// лямбды, переданные в функции над списками, проверяются с типом элементов списка.
+func (c *checker) callBuiltin(n *callNode, f *function, s *typeScope) (*Type, error) {
+	typ := f.fn.Type()
+
+	args := make([]*Type, len(n.args))
+	for i, arg := range n.args {
+		if _, ok := arg.(*lambdaNode); ok {
+			continue
+		}
+		t, err := c.check(arg, s)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = t
+	}
+
+	if err := f.checkCount(typ, n.pos, len(args)); err != nil {
+		return nil, err
+	}
+
+	var result *Type
+	var numbers []*Type //аргументы типа number
+	for i, arg := range n.args {
+		param := f.param(i)
+		if param == numberType {
+			numbers = append(numbers, args[i])
+		}
+
+		if l, ok := arg.(*lambdaNode); ok {
+			fn, err := c.lambda(l, lambdaParams(n.name, args), s)
+			if err != nil {
+				return nil, err
+			}
+			args[i], result = fn, fn.result
+		}
+
+		if !assignable(typeOfGo(param, make(map[reflect.Type]bool)), args[i]) {
+			return nil, argumentError(n, i, paramName(param), args[i])
+		}
+	}
+
+	return builtinResult(n.name, args, numbers, result, typ.Out(0)), nil
+}
+
+// lambdaParams возвращает типы параметров лямбды, переданной во встроенную функцию name.
+func lambdaParams(name string, args []*Type) []*Type {
+	elem := TypeAny()
+	if len(args) > 0 && args[0] != nil && args[0].kind == KindList {
+		elem = args[0].elem
+	}
+
+	if name == "reduce" {
+		acc := elem
+		if len(args) > 2 && args[2] != nil {
+			acc = unify(acc, args[2])
+		}
+		return []*Type{acc, elem, TypeInt()}
+	}
+	return []*Type{elem, TypeInt()}
+}
+
+// builtinResult уточняет тип результата функций над списками по типу лямбды.
+// тип результата number уточняется по аргументам типа number.
+func builtinResult(name string, args, numbers []*Type, result *Type, out reflect.Type) *Type {
+	list := ListOf(TypeAny())
+	if len(args) > 0 && args[0].kind == KindList {
+		list = args[0]
+	}
+
+	switch {
+	case result == nil:
+	case name == "map":
+		return ListOf(result)
+	case name == "filter" || name == "sortBy":
+		return list
+	case name == "flatMap" && result.kind == KindList:
+		return result
+	case name == "reduce":
+		return unify(list.elem, result)
+	}
+
+	//min(1, 2) — int, min(1, 2.5) — number, round(price, 2) — float
+	if out == numberType && len(numbers) > 0 {
+		res := numbers[0]
+		for _, arg := range numbers[1:] {
+			res = unify(res, arg)
+		}
+		if res.numeric() {
+			return res
+		}
+	}
+
+	return typeOfGo(out, make(map[reflect.Type]bool))
+}
+
+func argumentError(n *callNode, arg int, param string, typ *Type) error {
+	return &CallError{
+		Code: CodeArgumentType,
+		Pos:  n.pos,
+		Func: n.name,
+		Arg:  arg,
+		Msg:  "ожидалось " + param + ", получено " + typ.String(),
+	}
+}
+
+func typeError(code Code, pos Pos, op string, operands ...*Type) *TypeError {
+	types := make([]string, len(operands))
+	for i, operand := range operands {
+		types[i] = operand.String()
+	}
+	return &TypeError{Code: code, Pos: pos, Op: op, Types: types}
+}
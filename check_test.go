@@ -0,0 +1,414 @@
+package calc
+
+import (
+	"reflect"
+	"testing"
+)
+
+var schema = Schema{
+	"name":   TypeString(),
+	"age":    TypeInt(),
+	"price":  TypeFloat(),
+	"total":  TypeNumber(),
+	"active": TypeBool(),
+	"none":   TypeNull(),
+	"tags":   ListOf(TypeString()),
+	"scores": ListOf(TypeInt()),
+	"data":   TypeAny(),
+	"user":   TypeOf(user{}),
+	"config": ObjectOf(nil),
+	"point":  ObjectOf(map[string]*Type{"x": TypeInt(), "y": TypeInt()}),
+	"double": FuncOf(TypeInt(), TypeInt()),
+}
+
+func Test_Check(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected string
+	}{
+		{"1 + 2", "int"},
+		{"1 + 2.5", "float"},
+		{"age * 2", "int"},
+		{"age / 2", "float"},
+		{"age // 2 + age % 3", "int"},
+		{"age ** 2", "number"},
+		{"price ** 2", "float"},
+		{"total + age", "number"},
+		{"-age", "int"},
+		{"~age", "int"},
+		{"age & 3 | 4 << 1", "int"},
+		{"name + 'a'", "string"},
+		{"name < 'b'", "bool"},
+		{"age > 18 && active", "bool"},
+		{"!active", "bool"},
+		{"name == null", "bool"},
+		{"age == price", "bool"},
+		{"name =~ '^a'", "bool"},
+		{"name !~ data", "bool"},
+		{"'a' in tags", "bool"},
+		{"'x' in point", "bool"},
+		{"'и' in name", "bool"},
+		{"active ? age : price", "number"},
+		{"active ? name : null", "string"},
+		{"active ? name : 1", "any"},
+		{"[1, 2, 3]", "list[int]"},
+		{"[1, 2.5]", "list[number]"},
+		{"[]", "list"},
+		{"[tags, ['a']]", "list[list[string]]"},
+		{"[tags, []]", "list[list]"},
+		{"{'a': 1, b: name}", "{a: int, b: string}"},
+		{"tags[0]", "string"},
+		{"tags[1:]", "list[string]"},
+		{"name[0]", "string"},
+		{"name[:2]", "string"},
+		{"point.x + point['y']", "int"},
+		{"user.Name", "string"},
+		{"user.Address.City", "string"},
+		{"user.Manager.Name", "any"},
+		{"user.Meta.visits", "any"},
+		{"user.Tags", "list[string]"},
+		{"config.limits.max", "any"},
+		{"point?.z", "null"},
+		{"none?.a", "null"},
+		{"none ?? 5", "int"},
+		{"missing ?? 'a'", "string"},
+		{"point.z ?? 0", "int"},
+		{"data + 1", "any"},
+		{"data.a.b", "any"},
+		{"data(1, 2)", "any"},
+		{"data == 1", "bool"},
+		{"let x = 2; x * age", "int"},
+		{"let f = x => x * 2; f(3)", "any"},
+		{"double(age)", "int"},
+		{"len(name) + 1", "int"},
+		{"upper(name)", "string"},
+		{"round(price, 2)", "float"},
+		{"max(age, 1)", "int"},
+		{"min(age, price)", "number"},
+		{"abs(price)", "float"},
+		{"split(name, ',')", "list[string]"},
+		{"map(scores, x => x * 2)", "list[int]"},
+		{"map(tags, (x, i) => i)", "list[int]"},
+		{"filter(tags, x => len(x) > 2)", "list[string]"},
+		{"sortBy(scores, x => -x)", "list[int]"},
+		{"reduce(scores, (acc, x) => acc + x, 0)", "int"},
+		{"reduce(scores, (acc, x) => acc + x)", "int"},
+		{"flatMap(scores, x => [x, x])", "list[int]"},
+		{"count(scores, x => x > 1)", "int"},
+		{"any(tags, x => x == 'a')", "bool"},
+		{"groupBy(tags, x => x[0])", "map"},
+		{"x => x + 1", "function"},
+		{"format('%d', age)", "string"},
+		{"matches(name, 'a')", "bool"},
+		{"age; name", "string"},
+	}
+
+	for _, test := range tests {
+		typ, err := Check(test.program, schema)
+		if err != nil {
+			t.Errorf("%s: %v", test.program, err)
+			continue
+		}
+
+		if typ.String() != test.expected {
+			t.Errorf("%s: got %s, want %s", test.program, typ, test.expected)
+		}
+	}
+}
+
+func Test_Check_errors(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected error
+	}{
+		{
+			program: "name + 1",
+			expected: &TypeError{
+				Code:  CodeMismatchedTypes,
+				Pos:   Pos{Offset: 5, Line: 1, Column: 6},
+				Op:    "+",
+				Types: []string{"string", "int"},
+			},
+		},
+		{
+			program: "age && active",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 4, Line: 1, Column: 5},
+				Op:    "&&",
+				Types: []string{"int"},
+			},
+		},
+		{
+			program: "age || price",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 4, Line: 1, Column: 5},
+				Op:    "||",
+				Types: []string{"int"},
+			},
+		},
+		{
+			program: "active || name",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 7, Line: 1, Column: 8},
+				Op:    "||",
+				Types: []string{"string"},
+			},
+		},
+		{
+			program: "name - 'a'",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 5, Line: 1, Column: 6},
+				Op:    "-",
+				Types: []string{"string", "string"},
+			},
+		},
+		{
+			program: "-name",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 0, Line: 1, Column: 1},
+				Op:    "-",
+				Types: []string{"string"},
+			},
+		},
+		{
+			program: "age ? 1 : 2",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 4, Line: 1, Column: 5},
+				Op:    "?:",
+				Types: []string{"int"},
+			},
+		},
+		{
+			program: "age =~ 'a'",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 4, Line: 1, Column: 5},
+				Op:    "=~",
+				Types: []string{"int"},
+			},
+		},
+		{
+			program: "age in 5",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 4, Line: 1, Column: 5},
+				Op:    "in",
+				Types: []string{"int", "int"},
+			},
+		},
+		{
+			program: "1 + missing",
+			expected: &UnknownIdentifierError{
+				Code: CodeUnknownIdentifier,
+				Pos:  Pos{Offset: 4, Line: 1, Column: 5},
+				Name: "missing",
+			},
+		},
+		{
+			program: "user.Address.Zip",
+			expected: &MemberError{
+				Code: CodeUnknownMember,
+				Pos:  Pos{Offset: 12, Line: 1, Column: 13},
+				Path: "user.Address",
+				Name: "Zip",
+			},
+		},
+		{
+			program: "point['z']",
+			expected: &MemberError{
+				Code: CodeUnknownMember,
+				Pos:  Pos{Offset: 5, Line: 1, Column: 6},
+				Path: "point",
+				Name: "z",
+			},
+		},
+		{
+			program: "name.length",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 4, Line: 1, Column: 5},
+				Op:    ".",
+				Types: []string{"string"},
+			},
+		},
+		{
+			program: "tags['a']",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 4, Line: 1, Column: 5},
+				Op:    "[]",
+				Types: []string{"string"},
+			},
+		},
+		{
+			program: "upper(age)",
+			expected: &CallError{
+				Code: CodeArgumentType,
+				Pos:  Pos{Offset: 0, Line: 1, Column: 1},
+				Func: "upper",
+				Arg:  0,
+				Msg:  "ожидалось string, получено int",
+			},
+		},
+		{
+			program: "join(scores, ',')",
+			expected: &CallError{
+				Code: CodeArgumentType,
+				Pos:  Pos{Offset: 0, Line: 1, Column: 1},
+				Func: "join",
+				Arg:  0,
+				Msg:  "ожидалось list[string], получено list[int]",
+			},
+		},
+		{
+			program: "sqrt(1, 2)",
+			expected: &CallError{
+				Code: CodeArgumentCount,
+				Pos:  Pos{Offset: 0, Line: 1, Column: 1},
+				Func: "sqrt",
+				Arg:  -1,
+				Msg:  "ожидалось 1 аргументов, получено 2",
+			},
+		},
+		{
+			program: "double(name)",
+			expected: &CallError{
+				Code: CodeArgumentType,
+				Pos:  Pos{Offset: 0, Line: 1, Column: 1},
+				Func: "double",
+				Arg:  0,
+				Msg:  "ожидалось int, получено string",
+			},
+		},
+		{
+			program: "name(1)",
+			expected: &CallError{
+				Code: CodeNotCallable,
+				Pos:  Pos{Offset: 0, Line: 1, Column: 1},
+				Func: "name",
+				Arg:  -1,
+				Msg:  "значение типа string не является функцией",
+			},
+		},
+		{
+			program: "nope(1)",
+			expected: &UnknownIdentifierError{
+				Code: CodeUnknownFunction,
+				Pos:  Pos{Offset: 0, Line: 1, Column: 1},
+				Name: "nope",
+			},
+		},
+		{
+			program: "map(tags, x => x * 2)",
+			expected: &TypeError{
+				Code:  CodeMismatchedTypes,
+				Pos:   Pos{Offset: 17, Line: 1, Column: 18},
+				Op:    "*",
+				Types: []string{"string", "int"},
+			},
+		},
+		{
+			program: "let x = name; x + age",
+			expected: &TypeError{
+				Code:  CodeMismatchedTypes,
+				Pos:   Pos{Offset: 16, Line: 1, Column: 17},
+				Op:    "+",
+				Types: []string{"string", "int"},
+			},
+		},
+		{
+			program: "age +",
+			expected: &SyntaxError{
+				Code: CodeUnexpectedToken,
+				Pos:  Pos{Offset: 5, Line: 1, Column: 6},
+				Msg:  "ожидалось число | '('",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		_, err := Check(test.program, schema)
+		if !reflect.DeepEqual(err, test.expected) {
+			t.Errorf("%q: got %#v, want %#v", test.program, err, test.expected)
+		}
+	}
+}
+
+func Test_TypeOf(t *testing.T) {
+	tests := []struct {
+		val      any
+		expected string
+	}{
+		{nil, "null"},
+		{1, "int"},
+		{uint64(1), "number"},
+		{1.5, "float"},
+		{MustParseDecimal("1.5"), "number"},
+		{"a", "string"},
+		{true, "bool"},
+		{[]int{1}, "list[int]"},
+		{[]any{1}, "list"},
+		{map[string]int{}, "map"},
+		{map[int]string{}, "map[int]"},
+		{address{}, "{City: string, Street: string}"},
+		{&Audit{}, "{CreatedBy: string}"},
+		{func(s string) string { return s }, "function"},
+	}
+
+	for _, test := range tests {
+		if got := TypeOf(test.val).String(); got != test.expected {
+			t.Errorf("%#v: got %s, want %s", test.val, got, test.expected)
+		}
+	}
+}
+
+// Check принимает ключи словарей Go того типа, с которым их читает Eval.
+func Test_Check_mapKeys(t *testing.T) {
+	ns := namespace{"codes": map[int]string{404: "nf"}, "names": map[string]int{"a": 1}}
+	schema := Schema{"codes": TypeOf(ns["codes"]), "names": TypeOf(ns["names"])}
+
+	tests := []struct {
+		program string
+		ok      bool
+	}{
+		{"404 in codes", true},
+		{"codes[404]", true},
+		{"404.0 in codes", true},
+		{"'a' in names", true},
+		{"names['a']", true},
+		{"'a' in codes", false},
+		{"codes['a']", false},
+		{"1 in names", false},
+	}
+
+	for _, test := range tests {
+		_, checkErr := Check(test.program, schema)
+		_, evalErr := MustCompile(test.program).Eval(ns)
+
+		if (checkErr == nil) != test.ok || (evalErr == nil) != test.ok {
+			t.Errorf("%s: Check: %v, Eval: %v, want ok = %v", test.program, checkErr, evalErr, test.ok)
+		}
+	}
+}
+
+func Test_Type_shared(t *testing.T) {
+	//изменение полученного типа не затрагивает другие проверки
+	typ, err := Check("age + 1", schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	*typ = *TypeString()
+
+	if typ, err := Check("age + 1", schema); err != nil || typ.String() != "int" {
+		t.Errorf("age + 1: got %v, %v", typ, err)
+	}
+	if got := TypeInt().String(); got != "int" {
+		t.Errorf("TypeInt: got %s", got)
+	}
+}
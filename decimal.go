@@ -0,0 +1,532 @@
+package calc
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+/*
+в режиме DecimalMode числа — это Decimal: литералы разбираются как десятичные
+без потерь, а числа из Namespace и результаты функций переводятся в Decimal
+при первой операции над ними. float64 переводится по кратчайшему
+представлению, поэтому 0.1 из Namespace становится ровно 0.1.
+сложение, вычитание, умножение, //, %, побитовые операции над целыми и сравнения точные. деление и возведение
+в отрицательную степень округляются до Precision знаков после запятой.
+round, floor, ceil, trunc, abs, min, max, clamp, mod, div и rem тоже считают в Decimal,
+остальные встроенные функции получают float64 и работают как прежде.
+Decimal из Namespace включает десятичную арифметику и без DecimalMode.
+*/
+
+// Rounding — способ округления неточных результатов в DecimalMode.
+type Rounding uint8
+
+const (
+	RoundHalfEven Rounding = iota // к ближайшему, половина — к чётному (банковское)
+	RoundHalfUp                   // к ближайшему, половина — от нуля
+	RoundDown                     // отбрасывание цифр (к нулю)
+)
+
+type decimalContext struct {
+	precision int32 //знаков после запятой
+	rounding  Rounding
+}
+
+// defaultDecimal используется, когда Decimal пришёл из Namespace без DecimalMode.
+var defaultDecimal = &decimalContext{precision: 28, rounding: RoundHalfEven}
+
+// DecimalMode включает точную десятичную арифметику. precision — число знаков
+// после запятой у результатов деления, rounding — способ их округления.
+func DecimalMode(precision int, rounding Rounding) Option {
+	return func(o *options) {
+		o.decimal = &decimalContext{precision: int32(min(max(precision, 0), math.MaxInt32)), rounding: rounding}
+	}
+}
+
+// Decimal — точное десятичное число. нулевое значение равно 0.
+type Decimal struct {
+	coef  *big.Int //значение равно coef * 10^-scale, nil — ноль
+	scale int32    //не меньше 0
+}
+
+var decimalType = reflect.TypeOf(Decimal{})
+
+// maxDecimalExp ограничивает показатель в записи 1.5e3: 1e2000000000
+// потребовало бы числа из двух миллиардов цифр.
+const maxDecimalExp = 10000
+
+// ParseDecimal разбирает число вида -12.50 или 1.5e3. показатель должен быть
+// от -10000 до 10000.
+func ParseDecimal(s string) (Decimal, error) {
+	errSyntax := errors.New("некорректное десятичное число " + strconv.Quote(s))
+
+	mantissa, exp := s, int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		exp, err = strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil {
+			return Decimal{}, errSyntax
+		}
+		if exp < -maxDecimalExp || exp > maxDecimalExp {
+			return Decimal{}, errors.New("показатель десятичного числа " + strconv.Quote(s) + " должен быть от -10000 до 10000")
+		}
+		mantissa = s[:i]
+	}
+
+	digits := mantissa
+	if len(digits) > 0 && (digits[0] == '-' || digits[0] == '+') {
+		digits = digits[1:]
+	}
+
+	whole, frac, _ := strings.Cut(digits, ".")
+	if whole+frac == "" || strings.Trim(whole+frac, "0123456789") != "" {
+		return Decimal{}, errSyntax
+	}
+
+	coef, _ := new(big.Int).SetString(whole+frac, 10)
+	if mantissa[0] == '-' {
+		coef.Neg(coef)
+	}
+
+	scale := int64(len(frac)) - exp
+	if scale < 0 {
+		coef.Mul(coef, pow10(-scale))
+		scale = 0
+	}
+	if scale > math.MaxInt32 {
+		return Decimal{}, errSyntax
+	}
+
+	return Decimal{coef, int32(scale)}, nil
+}
+
+// MustParseDecimal аналогичен ParseDecimal, но паникует при ошибке.
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic("calc: MustParseDecimal: " + err.Error())
+	}
+	return d
+}
+
+// String возвращает точную запись числа без экспоненты, например -0.05.
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.int()).String()
+
+	if d.scale > 0 {
+		if pad := int(d.scale) + 1 - len(digits); pad > 0 {
+			digits = strings.Repeat("0", pad) + digits
+		}
+		digits = digits[:len(digits)-int(d.scale)] + "." + digits[len(digits)-int(d.scale):]
+	}
+
+	if d.int().Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// Float64 возвращает ближайшее к d число float64.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// Cmp сравнивает d и other: -1, если d < other, 0, если равны, +1, если d > other.
+func (d Decimal) Cmp(other Decimal) int {
+	l, r, _ := align(d, other)
+	return l.Cmp(r)
+}
+
+func (d Decimal) int() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return d.coef
+}
+
+func (d Decimal) sign() int { return d.int().Sign() }
+
+func (d Decimal) add(other Decimal) Decimal {
+	l, r, scale := align(d, other)
+	return Decimal{new(big.Int).Add(l, r), scale}
+}
+
+func (d Decimal) sub(other Decimal) Decimal {
+	l, r, scale := align(d, other)
+	return Decimal{new(big.Int).Sub(l, r), scale}
+}
+
+func (d Decimal) mul(other Decimal) Decimal {
+	return Decimal{new(big.Int).Mul(d.int(), other.int()), d.scale + other.scale}
+}
+
+func (d Decimal) neg() Decimal {
+	return Decimal{new(big.Int).Neg(d.int()), d.scale}
+}
+
+/*
+quo делит d на other (other не ноль) с округлением до ctx.precision знаков.
+лишние нули в конце отбрасываются, но не больше, чем до разности
+масштабов: 10 / 4 = 2.5, 1.00 / 1 = 1.00.
+*/
+func (d Decimal) quo(other Decimal, ctx *decimalContext) Decimal {
+	num, den := new(big.Int).Set(d.int()), new(big.Int).Set(other.int())
+
+	//d / other = d.coef * 10^(precision + other.scale - d.scale) / other.coef * 10^-precision
+	shift := int64(ctx.precision) + int64(other.scale) - int64(d.scale)
+	if shift >= 0 {
+		num.Mul(num, pow10(shift))
+	} else {
+		den.Mul(den, pow10(-shift))
+	}
+
+	res := Decimal{roundQuo(num, den, ctx.rounding), ctx.precision}
+	return res.trim(max(d.scale-other.scale, 0))
+}
+
+// floorDiv возвращает целую часть деления с округлением вниз, как у float64.
+func (d Decimal) floorDiv(other Decimal) Decimal {
+	l, r, _ := align(d, other)
+	q, m := new(big.Int).QuoRem(l, r, new(big.Int))
+	if m.Sign() != 0 && m.Sign() != r.Sign() {
+		q.Sub(q, big.NewInt(1))
+	}
+	return Decimal{q, 0}
+}
+
+// mod возвращает остаток со знаком делителя: d - other * floorDiv(d, other).
+func (d Decimal) mod(other Decimal) Decimal {
+	return d.sub(other.mul(d.floorDiv(other)))
+}
+
+// rem возвращает остаток со знаком делимого, как % у int64 в Go.
+func (d Decimal) rem(other Decimal) Decimal {
+	l, r, scale := align(d, other)
+	return Decimal{new(big.Int).Rem(l, r), scale}
+}
+
+// round округляет d до precision знаков после запятой.
+func (d Decimal) round(ctx *decimalContext) Decimal {
+	return d.roundTo(int(ctx.precision), ctx.rounding)
+}
+
+// roundTo округляет d до digits знаков после запятой способом rounding.
+// при digits < 0 округляются целые разряды: roundTo(1250, -2) == 1200 или 1300.
+func (d Decimal) roundTo(digits int, rounding Rounding) Decimal {
+	if int(d.scale) <= digits {
+		return d
+	}
+
+	q := roundQuo(new(big.Int).Set(d.int()), pow10(int64(d.scale)-int64(digits)), rounding)
+	if digits < 0 {
+		return Decimal{q.Mul(q, pow10(int64(-digits))), 0}
+	}
+	return Decimal{q, int32(digits)}
+}
+
+// floor, ceil и trunc округляют d до целого вниз, вверх и к нулю.
+func (d Decimal) floor() Decimal { return d.floorDiv(Decimal{big.NewInt(1), 0}) }
+func (d Decimal) ceil() Decimal  { return d.neg().floor().neg() }
+func (d Decimal) trunc() Decimal { return d.roundTo(0, RoundDown) }
+
+func (d Decimal) abs() Decimal {
+	if d.sign() < 0 {
+		return d.neg()
+	}
+	return d
+}
+
+// trim отбрасывает нули в конце дробной части, пока масштаб больше scale.
+func (d Decimal) trim(scale int32) Decimal {
+	coef := new(big.Int).Set(d.int())
+	ten, m := big.NewInt(10), new(big.Int)
+	for d.scale > scale {
+		q, _ := new(big.Int).QuoRem(coef, ten, m)
+		if m.Sign() != 0 {
+			break
+		}
+		coef = q
+		d.scale--
+	}
+	return Decimal{coef, d.scale}
+}
+
+// bigInt возвращает значение d как big.Int, если у d нет дробной части.
+// результат — копия, его можно изменять.
+func (d Decimal) bigInt() (*big.Int, bool) {
+	t := d.trim(0)
+	if t.scale != 0 {
+		return nil, false
+	}
+	return t.int(), true
+}
+
+// toInt возвращает d как int64 без потерь: после отбрасывания нулей дробной
+// части масштаб должен стать 0, а коэффициент — поместиться в int64.
+func (d Decimal) toInt() (int64, bool) {
+	t := d.trim(0)
+	if t.scale != 0 || !t.int().IsInt64() {
+		return 0, false
+	}
+	return t.int().Int64(), true
+}
+
+// roundQuo делит num на den и округляет частное способом rounding.
+func roundQuo(num, den *big.Int, rounding Rounding) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 || rounding == RoundDown {
+		return q
+	}
+
+	half := new(big.Int).Abs(r)
+	half.Lsh(half, 1)
+	cmp := half.Cmp(new(big.Int).Abs(den))
+
+	if cmp > 0 || cmp == 0 && (rounding == RoundHalfUp || q.Bit(0) == 1) {
+		if num.Sign()*den.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+// align приводит числа к общему масштабу.
+func align(l, r Decimal) (*big.Int, *big.Int, int32) {
+	switch {
+	case l.scale < r.scale:
+		return new(big.Int).Mul(l.int(), pow10(int64(r.scale-l.scale))), r.int(), r.scale
+	case l.scale > r.scale:
+		return l.int(), new(big.Int).Mul(r.int(), pow10(int64(l.scale-r.scale))), l.scale
+	default:
+		return l.int(), r.int(), l.scale
+	}
+}
+
+func pow10(n int64) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(n), nil)
+}
+
+// decimalOf приводит число к Decimal, NaN и бесконечности непредставимы.
+func decimalOf(val any) (Decimal, bool) {
+	switch val := val.(type) {
+	case Decimal:
+		return val, true
+	case int64:
+		return Decimal{big.NewInt(val), 0}, true
+	case float64:
+		if math.IsNaN(val) || math.IsInf(val, 0) {
+			return Decimal{}, false
+		}
+		d, err := ParseDecimal(strconv.FormatFloat(val, 'f', -1, 64))
+		return d, err == nil
+	default:
+		return Decimal{}, false
+	}
+}
+
+// toDecimal переводит числа int64 и float64 в Decimal, в том числе внутри списков и словарей.
+func toDecimal(val any) any {
+	switch val := val.(type) {
+	case int64:
+		return Decimal{big.NewInt(val), 0}
+	case float64:
+		if d, ok := decimalOf(val); ok {
+			return d
+		}
+		return val
+	case []any:
+		list := make([]any, len(val))
+		for i, item := range val {
+			list[i] = toDecimal(item)
+		}
+		return list
+	case map[string]any:
+		obj := make(map[string]any, len(val))
+		for key, item := range val {
+			obj[key] = toDecimal(item)
+		}
+		return obj
+	default:
+		return val
+	}
+}
+
+func isNumber(val any) bool {
+	switch val.(type) {
+	case int64, float64, Decimal:
+		return true
+	default:
+		return false
+	}
+}
+
+// compareNumbers сравнивает float64 и Decimal в любом сочетании.
+func compareNumbers(left, right any) int {
+	l, lok := decimalOf(left)
+	r, rok := decimalOf(right)
+	if lok && rok {
+		return l.Cmp(r)
+	}
+
+	//NaN и бесконечности сравниваются как float64
+	lf, _ := toFloat(left)
+	rf, _ := toFloat(right)
+	switch {
+	case lf < rf:
+		return -1
+	case lf > rf:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// toFloat возвращает значение числа как float64.
+func toFloat(val any) (float64, bool) {
+	switch val := val.(type) {
+	case int64:
+		return float64(val), true
+	case float64:
+		return val, true
+	case Decimal:
+		return val.Float64(), true
+	default:
+		return 0, false
+	}
+}
+
+// decimal выполняет операцию над числами, хотя бы одно из которых Decimal,
+// или над любыми числами в DecimalMode.
+func (n *binaryNode) decimal(left, right any) any {
+	l, lok := decimalOf(left)
+	r, rok := decimalOf(right)
+	if !lok || !rok {
+		return newTypeError(CodeInvalidOperand, n.pos, opName(n.op), left, right)
+	}
+
+	ctx := n.dec
+	if ctx == nil {
+		ctx = defaultDecimal
+	}
+
+	switch n.op {
+	case eqOp:
+		return l.Cmp(r) == 0
+	case notEqOp:
+		return l.Cmp(r) != 0
+	case lessOp:
+		return l.Cmp(r) < 0
+	case lessEqOp:
+		return l.Cmp(r) <= 0
+	case moreOp:
+		return l.Cmp(r) > 0
+	case moreEqOp:
+		return l.Cmp(r) >= 0
+	case addOp:
+		return l.add(r)
+	case subOp:
+		return l.sub(r)
+	case mulOp:
+		return l.mul(r)
+	case divOp, modOp, floorDivOp:
+		if r.sign() == 0 {
+			return &DivisionError{Code: CodeDivisionByZero, Pos: n.pos, Op: opName(n.op)}
+		}
+		switch n.op {
+		case divOp:
+			return l.quo(r, ctx)
+		case modOp:
+			return l.mod(r)
+		default:
+			return l.floorDiv(r)
+		}
+	case powOp:
+		return n.pow(l, r, ctx)
+	case bitAndOp, bitOrOp, bitXorOp, shlOp, shrOp:
+		return n.decimalBitwise(l, r)
+	default:
+		return newTypeError(CodeInvalidOperand, n.pos, opName(n.op), left, right)
+	}
+}
+
+/*
+decimalBitwise выполняет побитовые операции над целыми Decimal точно, через big.Int:
+9007199254740993 & 1 == 1, а не 0, как после приведения к float64.
+отрицательные числа ведут себя как в дополнительном коде, как у int64.
+сдвиг влево больше maxExactPower бит — OverflowError.
+*/
+func (n *binaryNode) decimalBitwise(l, r Decimal) any {
+	a, ok := l.bigInt()
+	if !ok {
+		return newTypeError(CodeNotInteger, n.pos, opName(n.op), l, r)
+	}
+	b, ok := r.bigInt()
+	if !ok {
+		return newTypeError(CodeNotInteger, n.pos, opName(n.op), l, r)
+	}
+
+	res := new(big.Int)
+	switch n.op {
+	case bitAndOp:
+		return Decimal{res.And(a, b), 0}
+	case bitOrOp:
+		return Decimal{res.Or(a, b), 0}
+	case bitXorOp:
+		return Decimal{res.Xor(a, b), 0}
+	}
+
+	if b.Sign() < 0 {
+		return newTypeError(CodeNegativeShift, n.pos, opName(n.op), l, r)
+	}
+
+	if n.op == shrOp {
+		//сдвиг дальше длины числа даёт 0 или -1
+		shift := uint(a.BitLen()) + 1
+		if b.IsInt64() && b.Int64() < int64(shift) {
+			shift = uint(b.Int64())
+		}
+		return Decimal{res.Rsh(a, shift), 0}
+	}
+
+	if a.Sign() == 0 {
+		return Decimal{res, 0}
+	}
+	if !b.IsInt64() || b.Int64() > maxExactPower {
+		return n.overflow()
+	}
+	return Decimal{res.Lsh(a, uint(b.Int64())), 0}
+}
+
+// maxExactPower ограничивает показатель степени, которая считается точно.
+const maxExactPower = 1 << 12
+
+/*
+pow возводит в целую степень точно, отрицательная степень округляется
+как деление. дробная степень считается через float64 и округляется до precision.
+*/
+func (n *binaryNode) pow(l, r Decimal, ctx *decimalContext) any {
+	exp, ok := r.toInt()
+	if ok && exp >= -maxExactPower && exp <= maxExactPower &&
+		int64(l.scale)*max(exp, -exp) <= math.MaxInt32 {
+		res := Decimal{new(big.Int).Exp(l.int(), big.NewInt(max(exp, -exp)), nil), l.scale * int32(max(exp, -exp))}
+		if exp >= 0 {
+			return res
+		}
+		if res.sign() == 0 {
+			return &DivisionError{Code: CodeDivisionByZero, Pos: n.pos, Op: opName(n.op)}
+		}
+		return Decimal{big.NewInt(1), 0}.quo(res, ctx)
+	}
+
+	res, ok := decimalOf(math.Pow(l.Float64(), r.Float64()))
+	if !ok {
+		return newTypeError(CodeInvalidOperand, n.pos, opName(n.op), l, r)
+	}
+	return res.round(ctx)
+}
@@ -0,0 +1,321 @@
+package calc
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_decimal(t *testing.T) {
+	ns := namespace{
+		"price":  19.99,
+		"qty":    3,
+		"tax":    0.2,
+		"prices": []float64{0.1, 0.2},
+		"exact":  MustParseDecimal("0.10"),
+		"items":  []any{map[string]any{"price": 0.1}, map[string]any{"price": 0.7}},
+	}
+
+	tests := []struct {
+		program  string
+		expected string
+	}{
+		{"0.1 + 0.2", "0.3"},
+		{"1.10 + 2.20", "3.30"},
+		{"price * qty", "59.97"},
+		{"price * qty * (1 + tax)", "71.964"},
+		{"price", "19.99"},
+		{"qty", "3"},
+		{"-price", "-19.99"},
+		{"10 / 4", "2.5"},
+		{"1.00 / 1", "1.00"},
+		{"10 / 3", "3.3333"},
+		{"2 / 3", "0.6667"},
+		{"-2 / 3", "-0.6667"},
+		{"7 // 2", "3"},
+		{"-7 // 2", "-4"},
+		{"-7 % 3", "2"},
+		{"7.5 % 2", "1.5"},
+		{"2 ** 10", "1024"},
+		{"1.1 ** 2", "1.21"},
+		{"2 ** (-2)", "0.25"},
+		{"4 ** 0.5", "2"},
+		{"6 & 3", "2"},
+		{"1 << 4", "16"},
+		{"~0", "-1"},
+		{"9007199254740993 & 1", "1"},
+		{"9007199254740993 | 0", "9007199254740993"},
+		{"9007199254740993 ^ 1", "9007199254740992"},
+		{"9007199254740993 >> 1", "4503599627370496"},
+		{"1 << 70", "1180591620717411303424"},
+		{"-8 >> 100", "-1"},
+		{"~9007199254740993", "-9007199254740994"},
+		{"-6 & 3", "2"},
+		{"2.0 | 1", "3"},
+		{"prices[0] + prices[1]", "0.3"},
+		{"exact * 3", "0.30"},
+		{"reduce(items, (acc, x) => acc + x.price, 0)", "0.8"},
+		{"round(2.675, 2)", "2.68"},
+		{"abs(-1.5)", "1.5"},
+		{"len('abc') - 1", "2"},
+	}
+
+	for _, test := range tests {
+		val := Calc(test.program, ns, DecimalMode(4, RoundHalfEven))
+		d, ok := val.(Decimal)
+		if !ok {
+			t.Errorf("%s: got %#v, want Decimal", test.program, val)
+			continue
+		}
+		if d.String() != test.expected {
+			t.Errorf("%s: got %s, want %s", test.program, d, test.expected)
+		}
+	}
+}
+
+func Test_decimal_values(t *testing.T) {
+	ns := namespace{"price": 0.1, "xs": []any{1, 2, 3}, "name": "tyson"}
+
+	tests := []struct {
+		program  string
+		expected any
+	}{
+		{"0.1 + 0.2 == 0.3", true},
+		{"price * 3 == 0.3", true},
+		{"1.0 == 1", true},
+		{"0.30 > 0.3", false},
+		{"0.3 >= 0.30", true},
+		{"[0.1 + 0.2] == [0.3]", true},
+		{"0.3 in [0.1 + 0.2]", true},
+		{"xs[1]", MustParseDecimal("2")},
+		{"xs[-1:]", []any{MustParseDecimal("3")}},
+		{"substr(name, 1, 2)", "ys"},
+		{"format('%v %.1f %d', 0.1 + 0.2, 1.25, 3)", "0.3 1.2 3"},
+		{"sortBy([0.3, 0.1, 0.2], (x) => x)", []any{MustParseDecimal("0.1"), MustParseDecimal("0.2"), MustParseDecimal("0.3")}},
+		{"groupBy([1.5, 1.50], (x) => x)", map[string]any{"1.5": []any{MustParseDecimal("1.5")}, "1.50": []any{MustParseDecimal("1.50")}}},
+		{"{'a': 1}", map[string]any{"a": MustParseDecimal("1")}},
+		{"name", "tyson"},
+	}
+
+	for _, test := range tests {
+		val := Calc(test.program, ns, DecimalMode(4, RoundHalfEven))
+		if !reflect.DeepEqual(val, test.expected) {
+			t.Errorf("%s: got %#v, want %#v", test.program, val, test.expected)
+		}
+	}
+}
+
+func Test_decimal_rounding(t *testing.T) {
+	tests := []struct {
+		program  string
+		rounding Rounding
+		expected string
+	}{
+		{"1 / 8", RoundHalfEven, "0.12"},
+		{"1 / 8", RoundHalfUp, "0.13"},
+		{"1 / 8", RoundDown, "0.12"},
+		{"3 / 8", RoundHalfEven, "0.38"},
+		{"3 / 8", RoundHalfUp, "0.38"},
+		{"3 / 8", RoundDown, "0.37"},
+		{"-1 / 8", RoundHalfEven, "-0.12"},
+		{"-1 / 8", RoundHalfUp, "-0.13"},
+		{"-1 / 8", RoundDown, "-0.12"},
+		{"2 / 3", RoundDown, "0.66"},
+		{"-2 / 3", RoundDown, "-0.66"},
+		{"-2 / 3", RoundHalfUp, "-0.67"},
+		{"2 ** (0 - 3)", RoundHalfEven, "0.12"},
+		{"round(0.125, 2)", RoundHalfEven, "0.12"},
+		{"round(0.125, 2)", RoundHalfUp, "0.13"},
+		{"round(0.135, 2)", RoundHalfEven, "0.14"},
+		{"round(-0.125, 2)", RoundDown, "-0.12"},
+		{"round(1250, -2)", RoundHalfEven, "1200"},
+		{"round(1350, -2)", RoundHalfEven, "1400"},
+		{"round(2.5)", RoundHalfEven, "2"},
+	}
+
+	for _, test := range tests {
+		val := Calc(test.program, nil, DecimalMode(2, test.rounding))
+		d, ok := val.(Decimal)
+		if !ok || d.String() != test.expected {
+			t.Errorf("%s (%d): got %v, want %s", test.program, test.rounding, val, test.expected)
+		}
+	}
+}
+
+func Test_decimal_builtins(t *testing.T) {
+	ns := namespace{"big": MustParseDecimal("12345678901234567.891")}
+
+	tests := []struct {
+		program  string
+		expected string
+	}{
+		{"round(12345678901234567.891, 2)", "12345678901234567.89"},
+		{"round(big, 1)", "12345678901234567.9"},
+		{"abs(-12345678901234567.891)", "12345678901234567.891"},
+		{"max(12345678901234567.891, 1)", "12345678901234567.891"},
+		{"min(-12345678901234567.891, 1)", "-12345678901234567.891"},
+		{"clamp(12345678901234567.891, 0, 12345678901234567.89)", "12345678901234567.89"},
+		{"clamp(-1.5, 0, 10)", "0"},
+		{"floor(-12345678901234567.891)", "-12345678901234568"},
+		{"ceil(12345678901234567.891)", "12345678901234568"},
+		{"trunc(-12345678901234567.891)", "-12345678901234567"},
+		{"floor(2)", "2"},
+		{"mod(9007199254740993, 2)", "1"},
+		{"mod(-7, 3)", "2"},
+		{"div(9007199254740993, 2)", "4503599627370496"},
+		{"div(-7.5, 2)", "-4"},
+		{"rem(-7.5, 2)", "-1.5"},
+		{"rem(12345678901234567.891, 10)", "7.891"},
+	}
+
+	for _, test := range tests {
+		val := Calc(test.program, ns, DecimalMode(10, RoundHalfEven))
+		d, ok := val.(Decimal)
+		if !ok || d.String() != test.expected {
+			t.Errorf("%s: got %v, want %s", test.program, val, test.expected)
+		}
+	}
+
+	//Decimal из Namespace округляется и без DecimalMode
+	if val := Calc("round(big, 2)", ns); !reflect.DeepEqual(val, MustParseDecimal("12345678901234567.89")) {
+		t.Errorf("round(big, 2): got %v, want 12345678901234567.89", val)
+	}
+}
+
+func Test_decimal_errors(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected error
+	}{
+		{
+			program:  "1 / 0",
+			expected: &DivisionError{Code: CodeDivisionByZero, Pos: Pos{Offset: 2, Line: 1, Column: 3}, Op: "/"},
+		},
+		{
+			program:  "1 % 0.0",
+			expected: &DivisionError{Code: CodeDivisionByZero, Pos: Pos{Offset: 2, Line: 1, Column: 3}, Op: "%"},
+		},
+		{
+			program:  "0 ** (-1)",
+			expected: &DivisionError{Code: CodeDivisionByZero, Pos: Pos{Offset: 2, Line: 1, Column: 3}, Op: "**"},
+		},
+		{
+			program: "1.5 & 1",
+			expected: &TypeError{
+				Code:  CodeNotInteger,
+				Pos:   Pos{Offset: 4, Line: 1, Column: 5},
+				Op:    "&",
+				Types: []string{"number", "number"},
+			},
+		},
+		{
+			program:  "1 << 5000",
+			expected: &OverflowError{Code: CodeIntegerOverflow, Pos: Pos{Offset: 2, Line: 1, Column: 3}, Op: "<<"},
+		},
+		{
+			program: "1 >> (0 - 1)",
+			expected: &TypeError{
+				Code:  CodeNegativeShift,
+				Pos:   Pos{Offset: 2, Line: 1, Column: 3},
+				Op:    ">>",
+				Types: []string{"number", "number"},
+			},
+		},
+		{
+			program: "0.1 + 'a'",
+			expected: &TypeError{
+				Code:  CodeMismatchedTypes,
+				Pos:   Pos{Offset: 4, Line: 1, Column: 5},
+				Op:    "+",
+				Types: []string{"number", "string"},
+			},
+		},
+		{
+			program: "(0 - 8) ** 0.5",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 8, Line: 1, Column: 9},
+				Op:    "**",
+				Types: []string{"number", "number"},
+			},
+		},
+		{
+			program: "[1, 2][0.5]",
+			expected: &TypeError{
+				Code:  CodeNotInteger,
+				Pos:   Pos{Offset: 6, Line: 1, Column: 7},
+				Op:    "[]",
+				Types: []string{"number"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, ok := Calc(test.program, nil, DecimalMode(4, RoundHalfEven)).(error)
+		if !ok {
+			t.Errorf("%q: expected error", test.program)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%q: got %#v, want %#v", test.program, got, test.expected)
+		}
+	}
+}
+
+// Decimal из Namespace считается точно и без DecimalMode.
+func Test_decimal_namespace(t *testing.T) {
+	ns := namespace{"amount": MustParseDecimal("0.1")}
+
+	val := Calc("amount + 0.2", ns)
+	d, ok := val.(Decimal)
+	if !ok || d.String() != "0.3" {
+		t.Errorf("got %#v, want 0.3", val)
+	}
+
+	a, b := 0.1, 0.2
+	if val := Calc("0.1 + 0.2", nil); val != a+b {
+		t.Errorf("float mode: got %#v", val)
+	}
+}
+
+func Test_ParseDecimal(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string
+		ok       bool
+	}{
+		{"0", "0", true},
+		{"-12.50", "-12.50", true},
+		{"+.5", "0.5", true},
+		{"1.", "1", true},
+		{"1.5e3", "1500", true},
+		{"15e-4", "0.0015", true},
+		{"-0.05", "-0.05", true},
+		{"123456789012345678901234567890.123", "123456789012345678901234567890.123", true},
+		{"", "", false},
+		{"-", "", false},
+		{"1.2.3", "", false},
+		{"1e", "", false},
+		{"abc", "", false},
+		{".e1", "", false},
+		{"1e10000", "1" + strings.Repeat("0", 10000), true},
+		{"1e2000000000", "", false},
+		{"1e-2000000000", "", false},
+	}
+
+	for _, test := range tests {
+		d, err := ParseDecimal(test.src)
+		if (err == nil) != test.ok {
+			t.Errorf("ParseDecimal(%q): unexpected error %v", test.src, err)
+			continue
+		}
+		if test.ok && d.String() != test.expected {
+			t.Errorf("ParseDecimal(%q): got %s, want %s", test.src, d, test.expected)
+		}
+	}
+
+	if (Decimal{}).String() != "0" {
+		t.Errorf("zero Decimal: got %s", Decimal{})
+	}
+}
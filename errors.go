@@ -0,0 +1,266 @@
+package calc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sergeysuprunchuk/calc/ast"
+)
+
+// Pos — позиция в исходном тексте, тот же тип, что и в дереве из Parse.
+type Pos = ast.Pos
+
+// Code — машиночитаемый код ошибки.
+type Code uint8
+
+const (
+	CodeEmptyExpression Code = iota + 1
+	CodeInvalidToken
+	CodeUnexpectedToken
+	CodeInvalidNumber
+	CodeMismatchedTypes
+	CodeInvalidOperand
+	CodeUnknownIdentifier
+	CodeDivisionByZero
+	CodeUnknownFunction
+	CodeNotCallable
+	CodeArgumentCount
+	CodeArgumentType
+	CodeCallFailed
+	CodeNotInteger
+	CodeNegativeShift
+	CodeIndexOutOfRange
+	CodeUnknownMember
+	CodeInvalidPattern
+	CodeIntegerOverflow
+	CodeLimitExceeded
+)
+
+var codeNames = [...]string{
+	CodeEmptyExpression:   "EmptyExpression",
+	CodeInvalidToken:      "InvalidToken",
+	CodeUnexpectedToken:   "UnexpectedToken",
+	CodeInvalidNumber:     "InvalidNumber",
+	CodeMismatchedTypes:   "MismatchedTypes",
+	CodeInvalidOperand:    "InvalidOperand",
+	CodeUnknownIdentifier: "UnknownIdentifier",
+	CodeDivisionByZero:    "DivisionByZero",
+	CodeUnknownFunction:   "UnknownFunction",
+	CodeNotCallable:       "NotCallable",
+	CodeArgumentCount:     "ArgumentCount",
+	CodeArgumentType:      "ArgumentType",
+	CodeCallFailed:        "CallFailed",
+	CodeNotInteger:        "NotInteger",
+	CodeNegativeShift:     "NegativeShift",
+	CodeIndexOutOfRange:   "IndexOutOfRange",
+	CodeUnknownMember:     "UnknownMember",
+	CodeInvalidPattern:    "InvalidPattern",
+	CodeIntegerOverflow:   "IntegerOverflow",
+	CodeLimitExceeded:     "LimitExceeded",
+}
+
+func (c Code) String() string {
+	if int(c) < len(codeNames) && codeNames[c] != "" {
+		return codeNames[c]
+	}
+	return fmt.Sprintf("Code(%d)", c)
+}
+
+// SyntaxError — ошибка разбора выражения.
+type SyntaxError struct {
+	Code Code
+	Pos  Pos
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string { return e.Pos.String() + ": " + e.Msg }
+
+// TypeError — оператор применён к операндам неподходящих типов.
+type TypeError struct {
+	Code  Code
+	Pos   Pos
+	Op    string
+	Types []string //типы операндов слева направо
+}
+
+func (e *TypeError) Error() string {
+	if e.Code == CodeMismatchedTypes {
+		return fmt.Sprintf("%s: оператор %s: типы операндов не совпадают: %s",
+			e.Pos, e.Op, strings.Join(e.Types, " и "))
+	}
+
+	switch e.Code {
+	case CodeNotInteger:
+		return fmt.Sprintf("%s: оператор %s применим только к целым числам", e.Pos, e.Op)
+	case CodeNegativeShift:
+		return fmt.Sprintf("%s: оператор %s: отрицательная величина сдвига", e.Pos, e.Op)
+	}
+
+	if len(e.Types) == 1 {
+		return fmt.Sprintf("%s: оператор %s не применим к типу %s", e.Pos, e.Op, e.Types[0])
+	}
+
+	return fmt.Sprintf("%s: оператор %s не применим к типам %s",
+		e.Pos, e.Op, strings.Join(e.Types, " и "))
+}
+
+// UnknownIdentifierError — идентификатор не найден в Namespace.
+type UnknownIdentifierError struct {
+	Code Code
+	Pos  Pos
+	Name string
+}
+
+func (e *UnknownIdentifierError) Error() string {
+	if e.Code == CodeUnknownFunction {
+		return fmt.Sprintf("%s: неизвестная функция %s", e.Pos, e.Name)
+	}
+	return fmt.Sprintf("%s: неизвестный идентификатор %s", e.Pos, e.Name)
+}
+
+// DivisionError — деление на ноль.
+type DivisionError struct {
+	Code Code
+	Pos  Pos
+	Op   string
+}
+
+func (e *DivisionError) Error() string {
+	return fmt.Sprintf("%s: оператор %s: деление на ноль", e.Pos, e.Op)
+}
+
+// OverflowError — результат целочисленной операции не помещается в int64.
+type OverflowError struct {
+	Code Code
+	Pos  Pos
+	Op   string
+}
+
+func (e *OverflowError) Error() string {
+	return fmt.Sprintf("%s: оператор %s: переполнение целого числа", e.Pos, e.Op)
+}
+
+// Limit — ограничение выполнения, заданное в Compile.
+type Limit uint8
+
+const (
+	LimitSteps          Limit = iota + 1 // MaxSteps
+	LimitStringLength                    // MaxStringLength
+	LimitCollectionSize                  // MaxCollectionSize
+)
+
+// LimitExceededError — выполнение превысило ограничение Limit со значением Max.
+type LimitExceededError struct {
+	Code  Code
+	Pos   Pos
+	Limit Limit
+	Max   int
+}
+
+func (e *LimitExceededError) Error() string {
+	switch e.Limit {
+	case LimitSteps:
+		return fmt.Sprintf("%s: превышено число шагов выполнения %d", e.Pos, e.Max)
+	case LimitStringLength:
+		return fmt.Sprintf("%s: длина строки превышает %d", e.Pos, e.Max)
+	default:
+		return fmt.Sprintf("%s: размер коллекции превышает %d", e.Pos, e.Max)
+	}
+}
+
+// IndexError — индекс за пределами списка или строки.
+type IndexError struct {
+	Code  Code
+	Pos   Pos
+	Index int
+	Len   int
+}
+
+func (e *IndexError) Error() string {
+	return fmt.Sprintf("%s: индекс %d вне диапазона [0, %d)", e.Pos, e.Index, e.Len)
+}
+
+// MemberError — у объекта нет поля или ключа Name. Path — путь до объекта,
+// например user.address, если его удалось определить.
+type MemberError struct {
+	Code Code
+	Pos  Pos
+	Path string
+	Name string
+}
+
+func (e *MemberError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%s: поле %s не найдено", e.Pos, e.Name)
+	}
+	return fmt.Sprintf("%s: поле %s не найдено в %s", e.Pos, e.Name, e.Path)
+}
+
+// PatternError — некорректное регулярное выражение, полученное во время выполнения.
+// ошибка компиляции из пакета regexp доступна через errors.Unwrap.
+type PatternError struct {
+	Code    Code
+	Pos     Pos
+	Pattern string
+	Err     error
+}
+
+func (e *PatternError) Error() string {
+	return fmt.Sprintf("%s: некорректное регулярное выражение: %s", e.Pos, e.Err)
+}
+
+func (e *PatternError) Unwrap() error { return e.Err }
+
+// CallError — ошибка вызова функции: неверное число или типы аргументов,
+// либо ошибка, которую вернула сама функция (доступна через errors.Unwrap).
+type CallError struct {
+	Code Code
+	Pos  Pos
+	Func string
+	Arg  int //номер аргумента начиная с нуля или -1
+	Msg  string
+	Err  error
+}
+
+func (e *CallError) Error() string {
+	msg := e.Msg
+	if e.Err != nil {
+		msg = e.Err.Error()
+	}
+
+	if e.Arg >= 0 {
+		return fmt.Sprintf("%s: %s: аргумент %d: %s", e.Pos, e.Func, e.Arg+1, msg)
+	}
+	return fmt.Sprintf("%s: %s: %s", e.Pos, e.Func, msg)
+}
+
+func (e *CallError) Unwrap() error { return e.Err }
+
+func newTypeError(code Code, pos Pos, op string, operands ...any) *TypeError {
+	types := make([]string, len(operands))
+	for i, operand := range operands {
+		types[i] = typeName(operand)
+	}
+	return &TypeError{Code: code, Pos: pos, Op: op, Types: types}
+}
+
+func typeName(val any) string {
+	switch val.(type) {
+	case nil:
+		return "null"
+	case int64, float64, Decimal:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case []any:
+		return "list"
+	case map[string]any:
+		return "map"
+	case *lambda:
+		return "function"
+	default:
+		return fmt.Sprintf("%T", val)
+	}
+}
@@ -0,0 +1,226 @@
+package calc
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_errors(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected error
+	}{
+		{
+			program: "1 + name",
+			expected: &TypeError{
+				Code:  CodeMismatchedTypes,
+				Pos:   Pos{Offset: 2, Line: 1, Column: 3},
+				Op:    "+",
+				Types: []string{"number", "string"},
+			},
+		},
+		{
+			program: "is_admin * is_admin",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 9, Line: 1, Column: 10},
+				Op:    "*",
+				Types: []string{"bool", "bool"},
+			},
+		},
+		{
+			program: "1 +\n-name",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 4, Line: 2, Column: 1},
+				Op:    "-",
+				Types: []string{"string"},
+			},
+		},
+		{
+			program: "!age",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 0, Line: 1, Column: 1},
+				Op:    "!",
+				Types: []string{"number"},
+			},
+		},
+		{
+			program: "- +name",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 2, Line: 1, Column: 3},
+				Op:    "+",
+				Types: []string{"string"},
+			},
+		},
+		{
+			program: "age & 1.5",
+			expected: &TypeError{
+				Code:  CodeNotInteger,
+				Pos:   Pos{Offset: 4, Line: 1, Column: 5},
+				Op:    "&",
+				Types: []string{"number", "number"},
+			},
+		},
+		{
+			program: "~0.5",
+			expected: &TypeError{
+				Code:  CodeNotInteger,
+				Pos:   Pos{Offset: 0, Line: 1, Column: 1},
+				Op:    "~",
+				Types: []string{"number"},
+			},
+		},
+		{
+			program: "1 << -1",
+			expected: &TypeError{
+				Code:  CodeNegativeShift,
+				Pos:   Pos{Offset: 2, Line: 1, Column: 3},
+				Op:    "<<",
+				Types: []string{"number", "number"},
+			},
+		},
+		{
+			program: "is_admin | is_admin",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 9, Line: 1, Column: 10},
+				Op:    "|",
+				Types: []string{"bool", "bool"},
+			},
+		},
+		{
+			program: "age in 1",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 4, Line: 1, Column: 5},
+				Op:    "in",
+				Types: []string{"number", "number"},
+			},
+		},
+		{
+			program: "age not in name",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 4, Line: 1, Column: 5},
+				Op:    "not in",
+				Types: []string{"number", "string"},
+			},
+		},
+		{
+			program: "age not 1",
+			expected: &SyntaxError{
+				Code: CodeUnexpectedToken,
+				Pos:  Pos{Offset: 8, Line: 1, Column: 9},
+				Msg:  "ожидалось in",
+			},
+		},
+		{
+			program: "age ? 1 : 2",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 4, Line: 1, Column: 5},
+				Op:    "?:",
+				Types: []string{"number"},
+			},
+		},
+		{
+			program: "age + salary",
+			expected: &UnknownIdentifierError{
+				Code: CodeUnknownIdentifier,
+				Pos:  Pos{Offset: 6, Line: 1, Column: 7},
+				Name: "salary",
+			},
+		},
+		{
+			program: "age / (age - 32)",
+			expected: &DivisionError{
+				Code: CodeDivisionByZero,
+				Pos:  Pos{Offset: 4, Line: 1, Column: 5},
+				Op:   "/",
+			},
+		},
+		{
+			program: "age % 0",
+			expected: &DivisionError{
+				Code: CodeDivisionByZero,
+				Pos:  Pos{Offset: 4, Line: 1, Column: 5},
+				Op:   "%",
+			},
+		},
+		{
+			program: "age // 0",
+			expected: &DivisionError{
+				Code: CodeDivisionByZero,
+				Pos:  Pos{Offset: 4, Line: 1, Column: 5},
+				Op:   "//",
+			},
+		},
+		{
+			program: "mod(age, 0)",
+			expected: &CallError{
+				Code: CodeCallFailed,
+				Pos:  Pos{Offset: 0, Line: 1, Column: 1},
+				Func: "mod",
+				Arg:  -1,
+				Err:  errDivisionByZero,
+			},
+		},
+		{
+			program: "age + 1 )",
+			expected: &SyntaxError{
+				Code: CodeUnexpectedToken,
+				Pos:  Pos{Offset: 8, Line: 1, Column: 9},
+				Msg:  "не удалось разобрать выражение",
+			},
+		},
+		{
+			program: "age # 1",
+			expected: &SyntaxError{
+				Code: CodeInvalidToken,
+				Pos:  Pos{Offset: 4, Line: 1, Column: 5},
+				Msg:  "неизвестный символ #",
+			},
+		},
+		{
+			program: "",
+			expected: &SyntaxError{
+				Code: CodeEmptyExpression,
+				Pos:  Pos{Offset: 0, Line: 1, Column: 1},
+				Msg:  "пустое выражение",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, ok := Calc(test.program, base).(error)
+		if !ok {
+			t.Errorf("%q: expected error", test.program)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%q: got %#v, want %#v", test.program, got, test.expected)
+		}
+	}
+}
+
+func Test_errors_As(t *testing.T) {
+	_, err := MustCompile("name + salary").Eval(base)
+
+	var target *UnknownIdentifierError
+	if !errors.As(err, &target) {
+		t.Fatalf("errors.As: got %T", err)
+	}
+
+	if target.Name != "salary" || target.Pos.String() != "1:8" {
+		t.Errorf("got %v", target)
+	}
+
+	if err.Error() != "1:8: неизвестный идентификатор salary" {
+		t.Errorf("Error(): got %q", err.Error())
+	}
+}
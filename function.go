@@ -0,0 +1,378 @@
+package calc
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+/*
+функции вызываются из выражения как name(arg, arg, ...).
+функцией может быть любое значение Go с типом func, положенное в Namespace,
+либо встроенная функция из builtins. результат — одно значение или (значение, error).
+аргументы перед вызовом проверяются и приводятся к типам параметров:
+число (int64, float64 или Decimal) подходит для любых числовых параметров: в целочисленные
+параметры — если не теряет точность, в параметры float64 — с приведением,
+строки и bool передаются как есть, параметр any принимает всё.
+*/
+
+// builtins — функции, доступные в любом выражении.
+var builtins = map[string]*function{}
+
+func register(name string, fn any) {
+	f, ok := newFunction(name, fn)
+	if !ok {
+		panic("calc: " + name + " не является функцией")
+	}
+	builtins[name] = f
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// number — параметр или результат встроенной функции, принимающий любое число:
+// int64, float64 и Decimal передаются как есть, без приведения к float64,
+// чтобы функция могла посчитать целые точно.
+type number any
+
+var numberType = reflect.TypeOf((*number)(nil)).Elem()
+
+// argumentCountError возвращают встроенные функции с необязательными аргументами,
+// чтобы о неверном числе аргументов сообщалось так же, как для обычных функций.
+type argumentCountError string
+
+func (e argumentCountError) Error() string { return string(e) }
+
+/*
+скрытые параметры встроенных функций идут первыми и не видны в выражении:
+*limits получает ограничения Eval, чтобы функция отказалась строить большое
+значение раньше, чем выделит под него память, а *decimalContext — точность
+и округление DecimalMode (nil без DecimalMode).
+*/
+var (
+	limitsType         = reflect.TypeOf((*limits)(nil))
+	decimalContextType = reflect.TypeOf((*decimalContext)(nil))
+)
+
+type function struct {
+	name   string
+	fn     reflect.Value
+	hidden int //число скрытых параметров
+}
+
+func newFunction(name string, fn any) (*function, bool) {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return nil, false
+	}
+
+	hidden := 0
+	for hidden < v.Type().NumIn() && (v.Type().In(hidden) == limitsType || v.Type().In(hidden) == decimalContextType) {
+		hidden++
+	}
+	return &function{name, v, hidden}, true
+}
+
+// param возвращает тип параметра для i-го аргумента выражения.
+func (f *function) param(i int) reflect.Type {
+	typ := f.fn.Type()
+	i += f.hidden
+	if typ.IsVariadic() && i >= typ.NumIn()-1 {
+		return typ.In(typ.NumIn() - 1).Elem()
+	}
+	return typ.In(i)
+}
+
+func (f *function) call(pos Pos, args []any) any {
+	return f.callWith(pos, args, nil, nil)
+}
+
+// callWith вызывает функцию, передавая lim и dec в скрытые параметры.
+func (f *function) callWith(pos Pos, args []any, lim *limits, dec *decimalContext) any {
+	typ := f.fn.Type()
+
+	if typ.NumOut() == 0 || typ.NumOut() > 2 ||
+		typ.NumOut() == 2 && typ.Out(1) != errorType {
+		return f.error(CodeNotCallable, pos, -1, "неподдерживаемая сигнатура "+typ.String())
+	}
+
+	if err := f.checkCount(typ, pos, len(args)); err != nil {
+		return err
+	}
+
+	in := make([]reflect.Value, 0, f.hidden+len(args))
+	for i := range f.hidden {
+		if typ.In(i) == limitsType {
+			in = append(in, reflect.ValueOf(lim))
+		} else {
+			in = append(in, reflect.ValueOf(dec))
+		}
+	}
+	for i, arg := range args {
+		param := f.param(i)
+		v, ok := convertArg(arg, param)
+		if !ok {
+			return f.error(CodeArgumentType, pos, i,
+				"ожидалось "+paramName(param)+", получено "+typeName(arg))
+		}
+		in = append(in, v)
+	}
+
+	out := f.fn.Call(in)
+
+	if len(out) == 2 && !out[1].IsNil() {
+		err := out[1].Interface().(error)
+		if e, ok := err.(argumentCountError); ok {
+			return f.error(CodeArgumentCount, pos, -1, string(e))
+		}
+		if e, ok := err.(limitError); ok {
+			return &LimitExceededError{Code: CodeLimitExceeded, Pos: pos, Limit: e.limit, Max: e.max}
+		}
+		if e, ok := err.(lambdaError); ok {
+			//ошибка внутри лямбды уже содержит свою позицию
+			return e.err
+		}
+		return &CallError{Code: CodeCallFailed, Pos: pos, Func: f.name, Arg: -1, Err: err}
+	}
+
+	return normalize(out[0].Interface())
+}
+
+func (f *function) checkCount(typ reflect.Type, pos Pos, count int) *CallError {
+	params := typ.NumIn() - f.hidden
+
+	if typ.IsVariadic() {
+		if count < params-1 {
+			return f.error(CodeArgumentCount, pos, -1,
+				fmt.Sprintf("ожидалось не меньше %d аргументов, получено %d", params-1, count))
+		}
+		return nil
+	}
+
+	if count != params {
+		return f.error(CodeArgumentCount, pos, -1,
+			fmt.Sprintf("ожидалось %d аргументов, получено %d", params, count))
+	}
+
+	return nil
+}
+
+func (f *function) error(code Code, pos Pos, arg int, msg string) *CallError {
+	return &CallError{Code: code, Pos: pos, Func: f.name, Arg: arg, Msg: msg}
+}
+
+// convertArg приводит значение выражения к типу параметра функции.
+func convertArg(val any, typ reflect.Type) (reflect.Value, bool) {
+	if typ == numberType {
+		if !isNumber(val) {
+			return reflect.Value{}, false
+		}
+		return reflect.ValueOf(val).Convert(typ), true
+	}
+
+	if val == nil {
+		switch typ.Kind() {
+		case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice, reflect.Func:
+			return reflect.Zero(typ), true
+		}
+		return reflect.Value{}, false
+	}
+
+	//Decimal передаётся в целочисленные параметры как целое, в остальные числовые — как float64
+	if d, ok := val.(Decimal); ok && typ.Kind() >= reflect.Int && typ.Kind() <= reflect.Float64 {
+		if t := d.trim(0); t.scale == 0 && t.int().IsUint64() && typ.Kind() >= reflect.Uint && typ.Kind() <= reflect.Uintptr {
+			u := reflect.New(typ).Elem()
+			if !u.OverflowUint(t.int().Uint64()) {
+				u.SetUint(t.int().Uint64())
+				return u, true
+			}
+		}
+
+		if i, ok := d.toInt(); ok && typ.Kind() < reflect.Float32 {
+			val = i
+		} else {
+			val = d.Float64()
+		}
+	}
+
+	v := reflect.ValueOf(val)
+
+	switch typ.Kind() {
+	case reflect.Interface:
+		if v.Type().Implements(typ) {
+			return v.Convert(typ), true
+		}
+		return reflect.Value{}, false
+
+	case reflect.Float32, reflect.Float64:
+		if f, ok := toFloat(val); ok {
+			return reflect.ValueOf(f).Convert(typ), true
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, ok := val.(int64); ok {
+			i := reflect.New(typ).Elem()
+			if !i.OverflowInt(n) {
+				i.SetInt(n)
+				return i, true
+			}
+		}
+
+		if f, ok := val.(float64); ok && f == math.Trunc(f) {
+			i := reflect.New(typ).Elem()
+			if f >= math.MinInt64 && f < math.MaxInt64 && !i.OverflowInt(int64(f)) {
+				i.SetInt(int64(f))
+				return i, true
+			}
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if n, ok := val.(int64); ok && n >= 0 {
+			u := reflect.New(typ).Elem()
+			if !u.OverflowUint(uint64(n)) {
+				u.SetUint(uint64(n))
+				return u, true
+			}
+		}
+
+		if f, ok := val.(float64); ok && f == math.Trunc(f) && f >= 0 {
+			u := reflect.New(typ).Elem()
+			if f < math.MaxUint64 && !u.OverflowUint(uint64(f)) {
+				u.SetUint(uint64(f))
+				return u, true
+			}
+		}
+
+	case reflect.String:
+		if _, ok := val.(string); ok {
+			return v.Convert(typ), true
+		}
+
+	case reflect.Bool:
+		if _, ok := val.(bool); ok {
+			return v.Convert(typ), true
+		}
+
+	case reflect.Slice:
+		if list, ok := val.([]any); ok && typ.Elem().Kind() != reflect.Interface {
+			s := reflect.MakeSlice(typ, len(list), len(list))
+			for i, item := range list {
+				elem, ok := convertArg(item, typ.Elem())
+				if !ok {
+					return reflect.Value{}, false
+				}
+				s.Index(i).Set(elem)
+			}
+			return s, true
+		}
+	}
+
+	if v.Type().AssignableTo(typ) {
+		return v, true
+	}
+
+	return reflect.Value{}, false
+}
+
+// paramName возвращает имя типа параметра в терминах выражений.
+func paramName(typ reflect.Type) string {
+	if typ == numberType {
+		return "number"
+	}
+
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Slice, reflect.Array:
+		//join([1, 2], ',') — ожидалось list[string], как в записи типов Check
+		if typ.Elem().Kind() == reflect.Interface {
+			return "list"
+		}
+		return "list[" + paramName(typ.Elem()) + "]"
+	case reflect.Map:
+		return "map"
+	case reflect.Pointer:
+		if typ == reflect.TypeOf((*lambda)(nil)) {
+			return "function"
+		}
+	case reflect.Interface:
+		if typ.NumMethod() == 0 {
+			return "any"
+		}
+	}
+	return typ.String()
+}
+
+type callNode struct {
+	name string
+	args []node
+	pos  Pos
+	dec  *decimalContext //не nil в DecimalMode
+}
+
+func (n *callNode) exec(namespace Namespace) any {
+	f, err := n.lookup(namespace)
+	if err != nil {
+		return err
+	}
+
+	args := make([]any, len(n.args))
+	for i, arg := range n.args {
+		val := arg.exec(namespace)
+		if _, ok := val.(error); ok {
+			return val
+		}
+		args[i] = val
+	}
+
+	if fn, ok := f.(*function); ok {
+		return fn.callWith(n.pos, args, nil, n.dec)
+	}
+	return f.call(n.pos, args)
+}
+
+// callable — то, что можно вызвать из выражения: функция Go или лямбда.
+type callable interface {
+	call(pos Pos, args []any) any
+}
+
+// lookup ищет функцию сначала в Namespace, затем среди встроенных,
+// так что Namespace (и переменные из let) может переопределить встроенную функцию.
+func (n *callNode) lookup(namespace Namespace) (callable, error) {
+	var val any
+	var found bool
+
+	if namespace != nil {
+		val, found = namespace.Get(n.name)
+		//null не скрывает встроенную функцию, например в Lenient
+		found = found && val != nil
+		if l, ok := val.(*lambda); found && ok {
+			return l, nil
+		}
+		if f, ok := newFunction(n.name, val); found && ok {
+			return f, nil
+		}
+	}
+
+	if f, ok := builtins[n.name]; ok {
+		return f, nil
+	}
+
+	if found {
+		return nil, &CallError{
+			Code: CodeNotCallable,
+			Pos:  n.pos,
+			Func: n.name,
+			Arg:  -1,
+			Msg:  "значение типа " + typeName(val) + " не является функцией",
+		}
+	}
+
+	return nil, &UnknownIdentifierError{Code: CodeUnknownFunction, Pos: n.pos, Name: n.name}
+}
@@ -0,0 +1,106 @@
+package calc
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var functions = namespace{
+	"name": "tyson",
+	"age":  32,
+	"max": func(a, b float64) float64 {
+		if a > b {
+			return a
+		}
+		return b
+	},
+	"sum": func(vals ...int) int {
+		var sum int
+		for _, val := range vals {
+			sum += val
+		}
+		return sum
+	},
+	"upper": strings.ToUpper,
+	"not":   func(b bool) bool { return !b },
+	"typeof": func(val any) string {
+		return typeName(val)
+	},
+	"now": func() float64 { return 1700000000 },
+	"sqrt": func(x float64) (float64, error) {
+		if x < 0 {
+			return 0, errors.New("отрицательный аргумент")
+		}
+		return x / 2, nil
+	},
+	"bad": func() {},
+}
+
+func Test_call(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected any
+	}{
+		{"max(16, 32)", 32.},
+		{"max(age, 16) + 1", 33.},
+		{"max(max(1, 2), max(3, (4)))", 4.},
+		{"sum()", int64(0)},
+		{"sum(1, 2, 3)", int64(6)},
+		{"upper(name)", "TYSON"},
+		{"not(age > 18)", false},
+		{"typeof(name + '!')", "string"},
+		{"now()", 1700000000.},
+		{"sqrt(64)", 32.},
+		{"`max`(1, 2)", 2.},
+	}
+
+	for _, test := range tests {
+		val := Calc(test.program, functions)
+		if !reflect.DeepEqual(val, test.expected) {
+			t.Errorf("%s: got %v, want %v", test.program, val, test.expected)
+		}
+	}
+}
+
+func Test_call_errors(t *testing.T) {
+	tests := []struct {
+		program string
+		code    Code
+		arg     int
+	}{
+		{"max(1)", CodeArgumentCount, -1},
+		{"max(1, 2, 3)", CodeArgumentCount, -1},
+		{"max(1, name)", CodeArgumentType, 1},
+		{"sum(1, 2.5)", CodeArgumentType, 1},
+		{"sum(1, 10.0 ** 100)", CodeArgumentType, 1},
+		{"not(1)", CodeArgumentType, 0},
+		{"name(1)", CodeNotCallable, -1},
+		{"bad()", CodeNotCallable, -1},
+		{"sqrt(-1)", CodeCallFailed, -1},
+	}
+
+	for _, test := range tests {
+		err, _ := Calc(test.program, functions).(error)
+
+		var target *CallError
+		if !errors.As(err, &target) {
+			t.Errorf("%s: expected *CallError, got %v", test.program, err)
+			continue
+		}
+
+		if target.Code != test.code || target.Arg != test.arg {
+			t.Errorf("%s: got %v %d, want %v %d", test.program, target.Code, target.Arg, test.code, test.arg)
+		}
+	}
+
+	var target *UnknownIdentifierError
+	if err, _ := Calc("missing(1)", functions).(error); !errors.As(err, &target) || target.Code != CodeUnknownFunction {
+		t.Errorf("missing(1): got %v", err)
+	}
+
+	if err, _ := Calc("sqrt(-1)", functions).(error); err.Error() != "1:1: sqrt: отрицательный аргумент" {
+		t.Errorf("sqrt(-1): got %q", err)
+	}
+}
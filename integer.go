@@ -0,0 +1,195 @@
+package calc
+
+import (
+	"math"
+	"math/bits"
+)
+
+/*
+целые числа — это int64: литералы без дробной части и целые значения из Namespace.
++, -, *, //, %, ** с неотрицательным показателем и побитовые операции над целыми
+дают целое, а при выходе за пределы int64 возвращают OverflowError.
+деление / всегда даёт float64: 7 / 2 == 3.5.
+uint64 больше math.MaxInt64 становится Decimal, побитовые операции над ним
+тоже точные: big & 1 == 1, big >> 1 == math.MaxInt64.
+если второй операнд — float64, целое сначала приводится к float64,
+как и в аргументах функций с параметрами float64.
+*/
+
+// promote приводит целое к float64, если второй операнд — float64.
+func promote(left, right any) (any, any) {
+	switch l := left.(type) {
+	case int64:
+		if _, ok := right.(float64); ok {
+			return float64(l), right
+		}
+	case float64:
+		if r, ok := right.(int64); ok {
+			return left, float64(r)
+		}
+	}
+	return left, right
+}
+
+func (n *binaryNode) integer(l, r int64) any {
+	switch n.op {
+	case eqOp:
+		return l == r
+	case notEqOp:
+		return l != r
+	case lessOp:
+		return l < r
+	case lessEqOp:
+		return l <= r
+	case moreOp:
+		return l > r
+	case moreEqOp:
+		return l >= r
+	case addOp:
+		res := l + r
+		if (res > l) != (r > 0) {
+			return n.overflow()
+		}
+		return res
+	case subOp:
+		res := l - r
+		if (res < l) != (r > 0) {
+			return n.overflow()
+		}
+		return res
+	case mulOp:
+		res, ok := mulInt(l, r)
+		if !ok {
+			return n.overflow()
+		}
+		return res
+	case divOp:
+		if r == 0 {
+			return &DivisionError{Code: CodeDivisionByZero, Pos: n.pos, Op: opName(n.op)}
+		}
+		return float64(l) / float64(r)
+	case modOp, floorDivOp:
+		if r == 0 {
+			return &DivisionError{Code: CodeDivisionByZero, Pos: n.pos, Op: opName(n.op)}
+		}
+		if n.op == floorDivOp && l == math.MinInt64 && r == -1 {
+			return n.overflow()
+		}
+
+		q, m := floorDivInt(l, r)
+		if n.op == modOp {
+			return m
+		}
+		return q
+	case powOp:
+		if r < 0 {
+			return math.Pow(float64(l), float64(r))
+		}
+		res, ok := powInt(l, r)
+		if !ok {
+			return n.overflow()
+		}
+		return res
+	case bitAndOp:
+		return l & r
+	case bitOrOp:
+		return l | r
+	case bitXorOp:
+		return l ^ r
+	case shlOp, shrOp:
+		if r < 0 {
+			return newTypeError(CodeNegativeShift, n.pos, opName(n.op), l, r)
+		}
+
+		if n.op == shrOp {
+			return l >> min(r, 63)
+		}
+
+		if l != 0 && (r >= 63 || (l<<r)>>r != l) {
+			return n.overflow()
+		}
+		return l << r
+	default:
+		return newTypeError(CodeInvalidOperand, n.pos, opName(n.op), l, r)
+	}
+}
+
+func (n *binaryNode) overflow() error {
+	return &OverflowError{Code: CodeIntegerOverflow, Pos: n.pos, Op: opName(n.op)}
+}
+
+// mulInt умножает с проверкой переполнения.
+func mulInt(l, r int64) (int64, bool) {
+	hi, lo := bits.Mul64(uint64(absInt(l)), uint64(absInt(r)))
+	if hi != 0 {
+		return 0, false
+	}
+
+	if (l < 0) != (r < 0) {
+		if lo > 1<<63 {
+			return 0, false
+		}
+		return -int64(lo), true
+	}
+
+	if lo > math.MaxInt64 {
+		return 0, false
+	}
+	return int64(lo), true
+}
+
+// floorDivInt возвращает частное с округлением вниз и остаток со знаком делителя.
+// r != 0, при math.MinInt64 и -1 частное переполняется.
+func floorDivInt(l, r int64) (q, m int64) {
+	q, m = l/r, l%r
+	if m != 0 && (m < 0) != (r < 0) {
+		q--
+		m += r
+	}
+	return q, m
+}
+
+// absInt возвращает модуль числа, для math.MinInt64 — его же (как uint64 это 1<<63).
+func absInt(i int64) int64 {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+// powInt возводит в неотрицательную степень с проверкой переполнения.
+func powInt(base, exp int64) (int64, bool) {
+	res := int64(1)
+	for exp > 0 {
+		var ok bool
+		if exp&1 == 1 {
+			if res, ok = mulInt(res, base); !ok {
+				return 0, false
+			}
+		}
+
+		exp >>= 1
+		if exp > 0 {
+			if base, ok = mulInt(base, base); !ok {
+				return 0, false
+			}
+		}
+	}
+	return res, true
+}
+
+func (n *unaryNode) integer(val int64) any {
+	switch n.op {
+	case addOp:
+		return val
+	case subOp:
+		if val == math.MinInt64 {
+			return &OverflowError{Code: CodeIntegerOverflow, Pos: n.pos, Op: opName(n.op)}
+		}
+		return -val
+	case bitNotOp:
+		return ^val
+	default:
+		return newTypeError(CodeInvalidOperand, n.pos, opName(n.op), val)
+	}
+}
@@ -0,0 +1,150 @@
+package calc
+
+import (
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+type userID int64
+
+func Test_integer(t *testing.T) {
+	ns := namespace{
+		"id":      int64(9007199254740993), //2^53 + 1, не представимо в float64
+		"big":     uint64(math.MaxUint64),
+		"max":     int64(math.MaxInt64),
+		"min":     int64(math.MinInt64),
+		"count":   uint32(7),
+		"owner":   userID(42),
+		"price":   2.5,
+		"ids":     []int64{1, 9007199254740993},
+		"offsets": []int8{-1, 1},
+	}
+
+	tests := []struct {
+		program  string
+		expected any
+	}{
+		{"16", int64(16)},
+		{"16.0", 16.},
+		{"id", int64(9007199254740993)},
+		{"id + 1", int64(9007199254740994)},
+		{"id - 9007199254740992", int64(1)},
+		{"id == 9007199254740993", true},
+		{"id == 9007199254740992", false},
+		{"ids[1] == id", true},
+		{"id in ids", true},
+		{"count * 2", int64(14)},
+		{"owner", int64(42)},
+		{"offsets", []any{int64(-1), int64(1)}},
+		{"max", int64(math.MaxInt64)},
+		{"min // 2", int64(math.MinInt64 / 2)},
+		{"big", Decimal{new(big.Int).SetUint64(math.MaxUint64), 0}},
+		{"big & 1", Decimal{big.NewInt(1), 0}},
+		{"big >> 1", Decimal{big.NewInt(math.MaxInt64), 0}},
+		{"big ^ big == 0", true},
+		{"big & max == max", true},
+		{"~big", Decimal{new(big.Int).Not(new(big.Int).SetUint64(math.MaxUint64)), 0}},
+		{"7 / 2", 3.5},
+		{"8 / 2", 4.},
+		{"7 // 2", int64(3)},
+		{"-7 % 3", int64(2)},
+		{"2 ** 62", int64(1 << 62)},
+		{"2 ** (-1)", .5},
+		{"2 ** -1", .5},
+		{"-2 ** 2", int64(-4)},
+		{"count * price", 17.5},
+		{"price * count", 17.5},
+		{"1 + 0.5", 1.5},
+		{"1 == 1.0", true},
+		{"2 < 2.5", true},
+		{"[1, 2] == [1.0, 2.0]", true},
+		{"~0", int64(-1)},
+		{"1 << 62", int64(1 << 62)},
+		{"-1 >> 100", int64(-1)},
+		{"6 & 3.0", 2.},
+		{"len('abc') * 2", int64(6)},
+		{"substr('abcdef', id - 9007199254740990)", "def"},
+		{"format('%d %.1f', id, count)", "9007199254740993 7.0"},
+		{"sortBy([3, 1.5, 2], (x) => x)", []any{1.5, int64(2), int64(3)}},
+		{"map([10, 20], (x, i) => i)", []any{int64(0), int64(1)}},
+	}
+
+	for _, test := range tests {
+		val := Calc(test.program, ns)
+		if !reflect.DeepEqual(val, test.expected) {
+			t.Errorf("%s: got %#v, want %#v", test.program, val, test.expected)
+		}
+	}
+}
+
+func Test_integer_errors(t *testing.T) {
+	ns := namespace{"max": int64(math.MaxInt64), "min": int64(math.MinInt64)}
+
+	overflow := func(offset int, op string) error {
+		return &OverflowError{Code: CodeIntegerOverflow, Pos: Pos{Offset: offset, Line: 1, Column: offset + 1}, Op: op}
+	}
+
+	tests := []struct {
+		program  string
+		expected error
+	}{
+		{"max + 1", overflow(4, "+")},
+		{"min - 1", overflow(4, "-")},
+		{"-min", overflow(0, "-")},
+		{"max * 2", overflow(4, "*")},
+		{"min * -1", overflow(4, "*")},
+		{"min // -1", overflow(4, "//")},
+		{"2 ** 63", overflow(2, "**")},
+		{"10 ** 100", overflow(3, "**")},
+		{"1 << 63", overflow(2, "<<")},
+		{"3 << 62", overflow(2, "<<")},
+		{"1 // 0", &DivisionError{Code: CodeDivisionByZero, Pos: Pos{Offset: 2, Line: 1, Column: 3}, Op: "//"}},
+		{"1 % 0", &DivisionError{Code: CodeDivisionByZero, Pos: Pos{Offset: 2, Line: 1, Column: 3}, Op: "%"}},
+		{"1 / 0", &DivisionError{Code: CodeDivisionByZero, Pos: Pos{Offset: 2, Line: 1, Column: 3}, Op: "/"}},
+		{
+			"1 << -1",
+			&TypeError{Code: CodeNegativeShift, Pos: Pos{Offset: 2, Line: 1, Column: 3}, Op: "<<", Types: []string{"number", "number"}},
+		},
+		{
+			"9223372036854775808",
+			&SyntaxError{Code: CodeInvalidNumber, Pos: Pos{Offset: 0, Line: 1, Column: 1}, Msg: "целое число 9223372036854775808 не помещается в int64"},
+		},
+	}
+
+	for _, test := range tests {
+		got, ok := Calc(test.program, ns).(error)
+		if !ok {
+			t.Errorf("%q: expected error", test.program)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%q: got %#v, want %#v", test.program, got, test.expected)
+		}
+	}
+}
+
+func Test_mulInt(t *testing.T) {
+	tests := []struct {
+		l, r int64
+		res  int64
+		ok   bool
+	}{
+		{3, 4, 12, true},
+		{-3, 4, -12, true},
+		{math.MinInt64, 1, math.MinInt64, true},
+		{math.MinInt64 / 2, 2, math.MinInt64, true},
+		{math.MaxInt64/2 + 1, 2, 0, false},
+		{math.MinInt64, -1, 0, false},
+		{1 << 32, 1 << 32, 0, false},
+	}
+
+	for _, test := range tests {
+		res, ok := mulInt(test.l, test.r)
+		if res != test.res || ok != test.ok {
+			t.Errorf("mulInt(%d, %d): got %d %v, want %d %v", test.l, test.r, res, ok, test.res, test.ok)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package calc
+
+import "fmt"
+
+/*
+лямбда x => x * 2 или (acc, x) => acc + x — значение, которое можно передать
+в функцию или связать через let и вызвать по имени. лямбда запоминает Namespace,
+в котором была создана, поэтому видит переменные из окружающих let.
+*/
+
+type lambdaNode struct {
+	params []string
+	body   node
+	pos    Pos
+}
+
+func (n *lambdaNode) exec(namespace Namespace) any {
+	return &lambda{n.params, n.body, namespace, n.pos, nil, nil}
+}
+
+type lambda struct {
+	params    []string
+	body      node
+	namespace Namespace
+	pos       Pos
+	code      *chunk  //байткод тела, nil — выполнять дерево
+	limits    *limits //ограничения Eval, в котором создана лямбда
+}
+
+func (l *lambda) call(pos Pos, args []any) any {
+	if len(args) != len(l.params) {
+		return &CallError{
+			Code: CodeArgumentCount,
+			Pos:  pos,
+			Func: "lambda",
+			Arg:  -1,
+			Msg:  fmt.Sprintf("ожидалось %d аргументов, получено %d", len(l.params), len(args)),
+		}
+	}
+
+	namespace := l.namespace
+	for i, param := range l.params {
+		namespace = &scope{namespace, param, args[i]}
+	}
+
+	if l.code != nil {
+		return l.code.run(namespace, l.limits)
+	}
+	return l.body.exec(namespace)
+}
+
+// lambdaError — ошибка, возникшая внутри лямбды, вызванной из встроенной функции.
+// function.call возвращает её без обёртки в CallError.
+type lambdaError struct{ err error }
+
+func (e lambdaError) Error() string { return e.err.Error() }
+
+/*
+apply вызывает лямбду из встроенной функции. лишние аргументы отбрасываются,
+поэтому в map можно передать как x => ..., так и (x, i) => ... с индексом.
+*/
+func (l *lambda) apply(args ...any) (any, error) {
+	if len(args) > len(l.params) {
+		args = args[:len(l.params)]
+	}
+
+	val := l.call(l.pos, args)
+	if err, ok := val.(error); ok {
+		return nil, lambdaError{err}
+	}
+
+	return val, nil
+}
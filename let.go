@@ -0,0 +1,52 @@
+package calc
+
+// letNode вычисляет val и выполняет body, в котором name связано с результатом.
+type letNode struct {
+	name string
+	val  node
+	body node
+	pos  Pos //позиция let
+}
+
+func (n *letNode) exec(namespace Namespace) any {
+	val := n.val.exec(namespace)
+	if _, ok := val.(error); ok {
+		return val
+	}
+
+	return n.body.exec(&scope{namespace, n.name, val})
+}
+
+// seqNode выполняет first ради ошибок и возвращает результат rest.
+type seqNode struct {
+	first node
+	rest  node
+}
+
+func (n *seqNode) exec(namespace Namespace) any {
+	val := n.first.exec(namespace)
+	if _, ok := val.(error); ok {
+		return val
+	}
+
+	return n.rest.exec(namespace)
+}
+
+// scope — Namespace с одной локальной переменной поверх родительского.
+type scope struct {
+	parent Namespace
+	name   string
+	val    any
+}
+
+func (s *scope) Get(key string) (any, bool) {
+	if key == s.name {
+		return s.val, true
+	}
+
+	if s.parent == nil {
+		return nil, false
+	}
+
+	return s.parent.Get(key)
+}
@@ -0,0 +1,121 @@
+package calc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_let(t *testing.T) {
+	ns := namespace{"price": 10, "qty": 3, "tax": 0.5, "name": "tyson"}
+
+	tests := []struct {
+		program  string
+		expected any
+	}{
+		{"let base = price * qty; base + base * tax", 45.},
+		{"let base = price * qty;\nlet total = base * (1 + tax);\nround(total, 2)", 45.},
+		{"let price = 1; price * qty", int64(3)},
+		{"let a = 1; let a = a + 1; a", int64(2)},
+		{"let xs = [1, 2, 3]; xs[len(xs) - 1]", int64(3)},
+		{"let user = {'name': name}; user.name", "tyson"},
+		{"let max = 5; max(max, 7)", int64(7)},
+		{"price; qty", int64(3)},
+		{"price * qty;", int64(30)},
+		{"let a = 2; a == 2 ? 'два' : 'нет';", "два"},
+	}
+
+	for _, test := range tests {
+		val := Calc(test.program, ns)
+		if !reflect.DeepEqual(val, test.expected) {
+			t.Errorf("%s: got %#v, want %#v", test.program, val, test.expected)
+		}
+	}
+
+	if _, ok := ns["base"]; ok {
+		t.Errorf("let must not modify namespace")
+	}
+}
+
+func Test_let_errors(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected error
+	}{
+		{
+			program: "let = 1; 2",
+			expected: &SyntaxError{
+				Code: CodeUnexpectedToken,
+				Pos:  Pos{Offset: 4, Line: 1, Column: 5},
+				Msg:  "ожидалось имя переменной",
+			},
+		},
+		{
+			program: "let a 1; a",
+			expected: &SyntaxError{
+				Code: CodeUnexpectedToken,
+				Pos:  Pos{Offset: 6, Line: 1, Column: 7},
+				Msg:  "ожидалось '='",
+			},
+		},
+		{
+			program: "let a = 1 a",
+			expected: &SyntaxError{
+				Code: CodeUnexpectedToken,
+				Pos:  Pos{Offset: 10, Line: 1, Column: 11},
+				Msg:  "ожидалось ';'",
+			},
+		},
+		{
+			program: "let a = 1;",
+			expected: &SyntaxError{
+				Code: CodeUnexpectedToken,
+				Pos:  Pos{Offset: 10, Line: 1, Column: 11},
+				Msg:  "ожидалось выражение после let",
+			},
+		},
+		{
+			program: "let a = 1; b",
+			expected: &UnknownIdentifierError{
+				Code: CodeUnknownIdentifier,
+				Pos:  Pos{Offset: 11, Line: 1, Column: 12},
+				Name: "b",
+			},
+		},
+		{
+			program: "missing; 1",
+			expected: &UnknownIdentifierError{
+				Code: CodeUnknownIdentifier,
+				Pos:  Pos{Offset: 0, Line: 1, Column: 1},
+				Name: "missing",
+			},
+		},
+		{
+			program: "let a = missing; 1",
+			expected: &UnknownIdentifierError{
+				Code: CodeUnknownIdentifier,
+				Pos:  Pos{Offset: 8, Line: 1, Column: 9},
+				Name: "missing",
+			},
+		},
+		{
+			program: "1;;2",
+			expected: &SyntaxError{
+				Code: CodeUnexpectedToken,
+				Pos:  Pos{Offset: 2, Line: 1, Column: 3},
+				Msg:  "ожидалось число | '('",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, ok := Calc(test.program, base).(error)
+		if !ok {
+			t.Errorf("%q: expected error", test.program)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%q: got %#v, want %#v", test.program, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package calc
+
+import (
+	"context"
+	"unicode/utf8"
+)
+
+/*
+ограничения защищают от формул, которые выполняются слишком долго или строят
+слишком большие значения: MaxSteps считает инструкции байткода, включая тела
+лямбд, вызванных из функций, а MaxStringLength и MaxCollectionSize проверяют
+результаты операторов, литералов и вызовов функций. repeat, padLeft, padRight,
+replace, join, split, map и flatMap получают ограничения скрытым параметром *limits
+и отказываются строить слишком большое значение до выделения памяти. EvalContext дополнительно
+прерывает выполнение после отмены ctx и возвращает ctx.Err().
+*/
+
+// MaxSteps ограничивает число шагов одного вызова Eval, 0 — без ограничения.
+func MaxSteps(n int) Option {
+	return func(o *options) { o.maxSteps = max(n, 0) }
+}
+
+// MaxStringLength ограничивает длину строк (в символах), которые создаёт выражение,
+// например конкатенацией, 0 — без ограничения.
+func MaxStringLength(n int) Option {
+	return func(o *options) { o.maxString = max(n, 0) }
+}
+
+// MaxCollectionSize ограничивает число элементов списков и словарей,
+// которые создаёт выражение, 0 — без ограничения.
+func MaxCollectionSize(n int) Option {
+	return func(o *options) { o.maxCollection = max(n, 0) }
+}
+
+// pollInterval — как часто run проверяет ctx.Done(), в шагах.
+const pollInterval = 64
+
+// limits — состояние одного вызова Eval, общее для программы и её лямбд.
+type limits struct {
+	ctx   context.Context
+	done  <-chan struct{}
+	steps int
+	opts  *options
+}
+
+// limits возвращает nil, если ограничений нет и ctx нельзя отменить:
+// тогда run не тратит время на проверки.
+func (o *options) limits(ctx context.Context) *limits {
+	done := ctx.Done()
+	if done == nil && o.maxSteps == 0 && o.maxString == 0 && o.maxCollection == 0 {
+		return nil
+	}
+	return &limits{ctx: ctx, done: done, opts: o}
+}
+
+// step учитывает очередную инструкцию.
+func (l *limits) step(in *instr) error {
+	l.steps++
+
+	if l.opts.maxSteps > 0 && l.steps > l.opts.maxSteps {
+		return &LimitExceededError{Code: CodeLimitExceeded, Pos: nodePos(in.node), Limit: LimitSteps, Max: l.opts.maxSteps}
+	}
+
+	if l.done != nil && l.steps%pollInterval == 1 {
+		select {
+		case <-l.done:
+			return l.ctx.Err()
+		default:
+		}
+	}
+
+	return nil
+}
+
+// check проверяет размер значения, созданного инструкцией.
+func (l *limits) check(in *instr, val any) error {
+	switch v := val.(type) {
+	case string:
+		//число символов не больше числа байт, поэтому руны считаются только для длинных строк
+		if max := l.opts.maxString; max > 0 && len(v) > max && utf8.RuneCountInString(v) > max {
+			return &LimitExceededError{Code: CodeLimitExceeded, Pos: nodePos(in.node), Limit: LimitStringLength, Max: max}
+		}
+	case []any:
+		return l.size(in, len(v))
+	case map[string]any:
+		return l.size(in, len(v))
+	}
+	return nil
+}
+
+// limitError возвращают встроенные функции со скрытым параметром *limits, когда результат
+// превысил бы ограничение. function.call превращает её в LimitExceededError
+// с позицией вызова.
+type limitError struct {
+	limit Limit
+	max   int
+}
+
+func (e limitError) Error() string {
+	return (&LimitExceededError{Limit: e.limit, Max: e.max}).Error()
+}
+
+// stringLen проверяет длину строки, которую функция собирается построить.
+// l может быть nil.
+func (l *limits) stringLen(n int) error {
+	if l != nil && l.opts.maxString > 0 && n > l.opts.maxString {
+		return limitError{LimitStringLength, l.opts.maxString}
+	}
+	return nil
+}
+
+// collectionLen проверяет размер списка, который функция собирается построить.
+// l может быть nil.
+func (l *limits) collectionLen(n int) error {
+	if l != nil && l.opts.maxCollection > 0 && n > l.opts.maxCollection {
+		return limitError{LimitCollectionSize, l.opts.maxCollection}
+	}
+	return nil
+}
+
+func (l *limits) size(in *instr, n int) error {
+	if max := l.opts.maxCollection; max > 0 && n > max {
+		return &LimitExceededError{Code: CodeLimitExceeded, Pos: nodePos(in.node), Limit: LimitCollectionSize, Max: max}
+	}
+	return nil
+}
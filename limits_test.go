@@ -0,0 +1,304 @@
+package calc
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func Test_limits(t *testing.T) {
+	xs := make([]any, 100)
+	for i := range xs {
+		xs[i] = int64(i)
+	}
+	ns := namespace{"xs": xs, "name": "tyson"}
+
+	tests := []struct {
+		program  string
+		opts     []Option
+		expected any
+	}{
+		{"reduce(map(xs, x => x * 2), (acc, x) => acc + x)", []Option{MaxSteps(2000)}, int64(9900)},
+		{"name + name", []Option{MaxStringLength(10)}, "tysontyson"},
+		{"[1, 2, 3]", []Option{MaxCollectionSize(3)}, []any{int64(1), int64(2), int64(3)}},
+		{"len(xs)", []Option{MaxCollectionSize(3)}, int64(100)},
+		{"len(xs[:3])", []Option{MaxCollectionSize(3)}, int64(3)},
+		{"'привет' + '!'", []Option{MaxStringLength(7)}, "привет!"},
+		{"name + name", []Option{MaxSteps(0), MaxStringLength(0)}, "tysontyson"},
+		{"repeat('ab', 5)", []Option{MaxStringLength(10)}, "ababababab"},
+		{"padLeft(name, 10, '.')", []Option{MaxStringLength(10)}, ".....tyson"},
+		{"split('a,b', ',')", []Option{MaxCollectionSize(2)}, []any{"a", "b"}},
+		{"len(flatMap(xs[:5], x => [x, x]))", []Option{MaxCollectionSize(10)}, int64(10)},
+		{"replace(name, 'son', 'sons')", []Option{MaxStringLength(6)}, "tysons"},
+		{"replace(name + name, 'tyson', '')", []Option{MaxStringLength(10)}, ""},
+		{"join(['ab', 'cd'], ', ')", []Option{MaxStringLength(6)}, "ab, cd"},
+	}
+
+	for _, test := range tests {
+		val := Calc(test.program, ns, test.opts...)
+		if !reflect.DeepEqual(val, test.expected) {
+			t.Errorf("%s: got %#v, want %#v", test.program, val, test.expected)
+		}
+	}
+}
+
+func Test_limits_errors(t *testing.T) {
+	xs := make([]any, 100)
+	for i := range xs {
+		xs[i] = int64(i)
+	}
+	ns := namespace{"xs": xs, "name": "tyson", "n": 1}
+
+	tests := []struct {
+		program  string
+		opts     []Option
+		expected error
+	}{
+		{
+			program: "let s = name + name; s + s",
+			opts:    []Option{MaxStringLength(15)},
+			expected: &LimitExceededError{
+				Code:  CodeLimitExceeded,
+				Pos:   Pos{Offset: 23, Line: 1, Column: 24},
+				Limit: LimitStringLength,
+				Max:   15,
+			},
+		},
+		{
+			program: "[n, n, n]",
+			opts:    []Option{MaxCollectionSize(2)},
+			expected: &LimitExceededError{
+				Code:  CodeLimitExceeded,
+				Pos:   Pos{Offset: 0, Line: 1, Column: 1},
+				Limit: LimitCollectionSize,
+				Max:   2,
+			},
+		},
+		{
+			program: "{a: n, b: n, c: n}",
+			opts:    []Option{MaxCollectionSize(2)},
+			expected: &LimitExceededError{
+				Code:  CodeLimitExceeded,
+				Pos:   Pos{Offset: 0, Line: 1, Column: 1},
+				Limit: LimitCollectionSize,
+				Max:   2,
+			},
+		},
+		{
+			program: "len(filter(xs, x => x > 10))",
+			opts:    []Option{MaxCollectionSize(50)},
+			expected: &LimitExceededError{
+				Code:  CodeLimitExceeded,
+				Pos:   Pos{Offset: 4, Line: 1, Column: 5},
+				Limit: LimitCollectionSize,
+				Max:   50,
+			},
+		},
+		{
+			program: "replace(name, '', name)",
+			opts:    []Option{MaxStringLength(20)},
+			expected: &LimitExceededError{
+				Code:  CodeLimitExceeded,
+				Pos:   Pos{Offset: 0, Line: 1, Column: 1},
+				Limit: LimitStringLength,
+				Max:   20,
+			},
+		},
+		{
+			program: "join([name, name, name], ', ')",
+			opts:    []Option{MaxStringLength(16)},
+			expected: &LimitExceededError{
+				Code:  CodeLimitExceeded,
+				Pos:   Pos{Offset: 0, Line: 1, Column: 1},
+				Limit: LimitStringLength,
+				Max:   16,
+			},
+		},
+		{
+			program: "'a' + repeat(name, 10000)",
+			opts:    []Option{MaxStringLength(100)},
+			expected: &LimitExceededError{
+				Code:  CodeLimitExceeded,
+				Pos:   Pos{Offset: 6, Line: 1, Column: 7},
+				Limit: LimitStringLength,
+				Max:   100,
+			},
+		},
+		{
+			program: "padRight(name, 101)",
+			opts:    []Option{MaxStringLength(100)},
+			expected: &LimitExceededError{
+				Code:  CodeLimitExceeded,
+				Pos:   Pos{Offset: 0, Line: 1, Column: 1},
+				Limit: LimitStringLength,
+				Max:   100,
+			},
+		},
+		{
+			program: "split(name, '')",
+			opts:    []Option{MaxCollectionSize(4)},
+			expected: &LimitExceededError{
+				Code:  CodeLimitExceeded,
+				Pos:   Pos{Offset: 0, Line: 1, Column: 1},
+				Limit: LimitCollectionSize,
+				Max:   4,
+			},
+		},
+		{
+			program: "len(map(xs, x => x))",
+			opts:    []Option{MaxCollectionSize(50)},
+			expected: &LimitExceededError{
+				Code:  CodeLimitExceeded,
+				Pos:   Pos{Offset: 4, Line: 1, Column: 5},
+				Limit: LimitCollectionSize,
+				Max:   50,
+			},
+		},
+		{
+			program: "flatMap(xs, x => [x, x]) ?? []",
+			opts:    []Option{MaxCollectionSize(150)},
+			expected: &LimitExceededError{
+				Code:  CodeLimitExceeded,
+				Pos:   Pos{Offset: 0, Line: 1, Column: 1},
+				Limit: LimitCollectionSize,
+				Max:   150,
+			},
+		},
+		{
+			program: "missing ?? name + name",
+			opts:    []Option{MaxStringLength(5)},
+			expected: &LimitExceededError{
+				Code:  CodeLimitExceeded,
+				Pos:   Pos{Offset: 16, Line: 1, Column: 17},
+				Limit: LimitStringLength,
+				Max:   5,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, ok := Calc(test.program, ns, test.opts...).(error)
+		if !ok {
+			t.Errorf("%q: expected error", test.program)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%q: got %#v, want %#v", test.program, got, test.expected)
+		}
+	}
+}
+
+// Test_limits_builtins проверяет, что функции отказываются от большого результата
+// до того, как выделят под него память.
+func Test_limits_builtins(t *testing.T) {
+	programs := []string{
+		"repeat('ab', 8000000)",
+		"padLeft('a', 16000000)",
+		"len(split(repeat(',', 100000), ','))",
+		"replace(s, '', s)",
+		"join(words, s)",
+	}
+
+	words := make([]string, 1000)
+	for i := range words {
+		words[i] = strings.Repeat("a", 2000)
+	}
+	ns := namespace{"s": strings.Repeat("b", 100000), "words": words}
+
+	for _, src := range programs {
+		p := MustCompile(src, MaxStringLength(200000), MaxCollectionSize(1000))
+
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		_, err := p.Eval(ns)
+		runtime.ReadMemStats(&after)
+
+		var limit *LimitExceededError
+		if !errors.As(err, &limit) {
+			t.Errorf("%s: got %v, want LimitExceededError", src, err)
+		}
+		if n := after.TotalAlloc - before.TotalAlloc; n > 1<<20 {
+			t.Errorf("%s: allocated %d bytes", src, n)
+		}
+	}
+}
+
+func Test_MaxSteps(t *testing.T) {
+	xs := make([]any, 1000)
+	for i := range xs {
+		xs[i] = int64(i)
+	}
+
+	p := MustCompile("reduce(xs, (acc, x) => acc + x, 0)", MaxSteps(500))
+
+	_, err := p.Eval(namespace{"xs": xs})
+
+	var limit *LimitExceededError
+	if !errors.As(err, &limit) || limit.Limit != LimitSteps || limit.Max != 500 {
+		t.Fatalf("got %v", err)
+	}
+
+	//счётчик шагов у каждого вызова свой
+	if val, err := p.Eval(namespace{"xs": xs[:10]}); err != nil || val != int64(45) {
+		t.Errorf("got %v, %v", val, err)
+	}
+}
+
+func Test_EvalContext(t *testing.T) {
+	xs := make([]any, 1000)
+	for i := range xs {
+		xs[i] = int64(i)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls int
+	ns := namespace{
+		"xs": xs,
+		"stop": func(x int) int {
+			calls++
+			cancel()
+			return x
+		},
+	}
+
+	p := MustCompile("map(xs, x => stop(x))")
+
+	if _, err := p.EvalContext(ctx, ns); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want %v", err, context.Canceled)
+	}
+
+	if calls >= len(xs) {
+		t.Errorf("stop called %d times after cancel", calls)
+	}
+
+	if _, err := p.EvalContext(ctx, ns); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled ctx: got %v", err)
+	}
+
+	if val, err := p.EvalContext(context.Background(), namespace{"xs": []any{}, "stop": ns["stop"]}); err != nil || len(val.([]any)) != 0 {
+		t.Errorf("got %v, %v", val, err)
+	}
+}
+
+func Test_LimitExceededError(t *testing.T) {
+	tests := []struct {
+		err      *LimitExceededError
+		expected string
+	}{
+		{&LimitExceededError{CodeLimitExceeded, Pos{Offset: 0, Line: 1, Column: 1}, LimitSteps, 10}, "1:1: превышено число шагов выполнения 10"},
+		{&LimitExceededError{CodeLimitExceeded, Pos{Offset: 4, Line: 1, Column: 5}, LimitStringLength, 3}, "1:5: длина строки превышает 3"},
+		{&LimitExceededError{CodeLimitExceeded, Pos{Offset: 4, Line: 1, Column: 5}, LimitCollectionSize, 3}, "1:5: размер коллекции превышает 3"},
+	}
+
+	for _, test := range tests {
+		if got := test.err.Error(); got != test.expected {
+			t.Errorf("got %q, want %q", got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package calc
+
+import (
+	"math"
+	"reflect"
+)
+
+/*
+списки представлены как []any. срезы и массивы Go из Namespace и результаты функций
+приводятся к []any в normalize, поэтому узлы работают только с этим типом.
+[]any с уже приведёнными элементами не копируется. исключение — индекс и срез
+идентификатора: xs[0] и xs[1:3] читают срез Go через reflect, не копируя его целиком.
+строки индексируются и режутся по рунам так же, как списки.
+*/
+
+type listNode struct {
+	items []node
+	pos   Pos
+}
+
+func (n *listNode) exec(namespace Namespace) any {
+	list := make([]any, len(n.items))
+	for i, item := range n.items {
+		val := item.exec(namespace)
+		if _, ok := val.(error); ok {
+			return val
+		}
+		list[i] = val
+	}
+	return list
+}
+
+type indexNode struct {
+	val   node
+	index node
+	pos   Pos //позиция '['
+}
+
+func (n *indexNode) exec(namespace Namespace) any {
+	val := sequence(n.val, namespace)
+	if _, ok := val.(error); ok {
+		return val
+	}
+
+	index := n.index.exec(namespace)
+	if _, ok := index.(error); ok {
+		return index
+	}
+
+	return n.apply(val, index)
+}
+
+// apply возвращает элемент вычисленного списка, строки или объекта.
+func (n *indexNode) apply(val, index any) any {
+	if isObject(val) {
+		return n.member(val, index)
+	}
+
+	length, ok := sequenceLen(val)
+	if !ok {
+		return newTypeError(CodeInvalidOperand, n.pos, "[]", val)
+	}
+
+	i, err := n.toIndex(index)
+	if err != nil {
+		return err
+	}
+
+	if i < 0 {
+		i += length
+	}
+
+	if i < 0 || i >= length {
+		f, _ := toFloat(index)
+		return &IndexError{Code: CodeIndexOutOfRange, Pos: n.pos, Index: int(f), Len: length}
+	}
+
+	switch v := val.(type) {
+	case string:
+		return string([]rune(v)[i])
+	case []any:
+		return v[i]
+	default:
+		return normalize(reflect.ValueOf(val).Index(i).Interface())
+	}
+}
+
+// member обрабатывает obj["key"] для словарей и структур.
+func (n *indexNode) member(val, key any) any {
+	res, status := member(val, key)
+	switch status {
+	case memberFound:
+		return res
+	case memberMissing:
+		name, _ := key.(string)
+		return &MemberError{Code: CodeUnknownMember, Pos: n.pos, Path: memberPath(n.val), Name: name}
+	default:
+		return newTypeError(CodeInvalidOperand, n.pos, "[]", val, key)
+	}
+}
+
+func (n *indexNode) toIndex(index any) (int, error) {
+	f, ok := toFloat(index)
+	if !ok {
+		return 0, newTypeError(CodeInvalidOperand, n.pos, "[]", index)
+	}
+
+	i, ok := toInt(f)
+	if !ok || i < math.MinInt32 || i > math.MaxInt32 {
+		return 0, newTypeError(CodeNotInteger, n.pos, "[]", index)
+	}
+
+	return int(i), nil
+}
+
+type sliceNode struct {
+	val  node
+	from node //nil, если граница опущена
+	to   node
+	pos  Pos
+}
+
+/*
+срез xs[from:to] ведёт себя как в Python: отрицательные границы отсчитываются
+от конца, выход за пределы обрезается, а from >= to даёт пустой список.
+*/
+func (n *sliceNode) exec(namespace Namespace) any {
+	val := sequence(n.val, namespace)
+	if _, ok := val.(error); ok {
+		return val
+	}
+
+	length, err := n.length(val)
+	if err != nil {
+		return err
+	}
+
+	from, err := n.bound(n.from, namespace, 0, length)
+	if err != nil {
+		return err
+	}
+
+	to, err := n.bound(n.to, namespace, length, length)
+	if err != nil {
+		return err
+	}
+
+	return n.cut(val, from, to)
+}
+
+// length возвращает длину списка или строки, которую режет срез.
+func (n *sliceNode) length(val any) (int, error) {
+	length, ok := sequenceLen(val)
+	if !ok {
+		return 0, newTypeError(CodeInvalidOperand, n.pos, "[:]", val)
+	}
+	return length, nil
+}
+
+// cut возвращает часть val между границами, уже приведёнными к диапазону [0, length].
+func (n *sliceNode) cut(val any, from, to int) any {
+	to = max(from, to)
+
+	if s, ok := val.(string); ok {
+		return string([]rune(s)[from:to])
+	}
+
+	list, ok := val.([]any)
+	if !ok {
+		//срез Go из identNode.sequence: приводятся только элементы среза
+		v, res := reflect.ValueOf(val), make([]any, to-from)
+		for i := range res {
+			res[i] = normalize(v.Index(from + i).Interface())
+		}
+		return res
+	}
+	//копия, чтобы результат не делил память со значением из Namespace
+	return append([]any{}, list[from:to]...)
+}
+
+func (n *sliceNode) bound(b node, namespace Namespace, def, length int) (int, error) {
+	if b == nil {
+		return def, nil
+	}
+
+	val := b.exec(namespace)
+	if err, ok := val.(error); ok {
+		return 0, err
+	}
+
+	return n.index(val, length)
+}
+
+// index приводит вычисленную границу к диапазону [0, length].
+func (n *sliceNode) index(val any, length int) (int, error) {
+	f, ok := toFloat(val)
+	if !ok {
+		return 0, newTypeError(CodeInvalidOperand, n.pos, "[:]", val)
+	}
+
+	i, ok := toInt(f)
+	if !ok {
+		return 0, newTypeError(CodeNotInteger, n.pos, "[:]", val)
+	}
+
+	if i < 0 {
+		i += int64(length)
+	}
+
+	return int(min(max(i, 0), int64(length))), nil
+}
+
+// sequenceLen возвращает длину списка или строки (в рунах).
+func sequenceLen(val any) (int, bool) {
+	switch val := val.(type) {
+	case []any:
+		return len(val), true
+	case string:
+		return len([]rune(val)), true
+	default:
+		//срез Go из identNode.sequence
+		if goSequence(val) {
+			return reflect.ValueOf(val).Len(), true
+		}
+		return 0, false
+	}
+}
+
+// goSequence сообщает, что val — срез или массив Go.
+func goSequence(val any) bool {
+	kind := reflect.ValueOf(val).Kind()
+	return kind == reflect.Slice || kind == reflect.Array
+}
+
+// toList приводит срез или массив Go к списку. []any, элементы которого
+// уже приведены, возвращается без копирования.
+func toList(v reflect.Value) []any {
+	if list, ok := v.Interface().([]any); ok && list != nil && normalized(list) {
+		return list
+	}
+
+	list := make([]any, v.Len())
+	for i := range list {
+		list[i] = normalize(v.Index(i).Interface())
+	}
+	return list
+}
+
+// normalized сообщает, что normalize не изменит ни один элемент списка.
+func normalized(list []any) bool {
+	for _, val := range list {
+		switch v := val.(type) {
+		case nil, bool, int64, float64, string, Decimal:
+		case map[string]any:
+			if v == nil {
+				return false
+			}
+		case []any:
+			if v == nil || !normalized(v) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// equal сравнивает значения на равенство, списки и словари — поэлементно.
+func equal(left, right any) bool {
+	if isNumber(left) && isNumber(right) {
+		l, lok := decimalOf(left)
+		r, rok := decimalOf(right)
+		if lok && rok {
+			return l.Cmp(r) == 0
+		}
+		return left == right
+	}
+
+	if l, ok := left.(map[string]any); ok {
+		r, ok := right.(map[string]any)
+		return ok && equalMap(l, r)
+	}
+
+	l, ok := left.([]any)
+	if !ok {
+		return reflect.DeepEqual(left, right)
+	}
+
+	r, ok := right.([]any)
+	if !ok || len(l) != len(r) {
+		return false
+	}
+
+	for i := range l {
+		if !equal(l[i], r[i]) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,213 @@
+package calc
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var lists = namespace{
+	"xs":     []int{10, 20, 30, 40, 50},
+	"names":  []string{"tyson", "paul"},
+	"matrix": [2][2]float64{{1, 2}, {3, 4}},
+	"empty":  []any(nil),
+	"mixed":  []any{1, "a", true, []uint8{1}},
+}
+
+func Test_list(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected any
+	}{
+		{"[]", []any{}},
+		{"[1, 2 + 3, 'a', [1 == 1]]", []any{int64(1), int64(5), "a", []any{true}}},
+		{"xs", []any{int64(10), int64(20), int64(30), int64(40), int64(50)}},
+		{"mixed", []any{int64(1), "a", true, []any{int64(1)}}},
+		{"empty", []any{}},
+		{"xs[0]", int64(10)},
+		{"xs[4]", int64(50)},
+		{"xs[-1]", int64(50)},
+		{"xs[-5]", int64(10)},
+		{"xs[1 + 1] * 2", int64(60)},
+		{"matrix[1][0]", 3.},
+		{"[[1, 2], [3, 4]][0][1]", int64(2)},
+		{"names[1]", "paul"},
+		{"xs[1:3]", []any{int64(20), int64(30)}},
+		{"xs[:2]", []any{int64(10), int64(20)}},
+		{"xs[3:]", []any{int64(40), int64(50)}},
+		{"xs[:]", []any{int64(10), int64(20), int64(30), int64(40), int64(50)}},
+		{"xs[-2:]", []any{int64(40), int64(50)}},
+		{"xs[:-3]", []any{int64(10), int64(20)}},
+		{"xs[3:1]", []any{}},
+		{"xs[-100:100]", []any{int64(10), int64(20), int64(30), int64(40), int64(50)}},
+		{"xs[1:4][1:][0]", int64(30)},
+		{"'привет'[0]", "п"},
+		{"'привет'[-1]", "т"},
+		{"'привет мир'[7:]", "мир"},
+		{"name[:2]", "ty"},
+		{"len(xs)", int64(5)},
+		{"len([])", int64(0)},
+		{"xs == [10, 20, 30, 40, 50]", true},
+		{"xs[:2] == [10, 20]", true},
+		{"xs != [10, 20]", true},
+		{"[1, [2, 'a']] == [1, [2, 'a']]", true},
+		{"[1, [2, 'a']] == [1, [2, 'b']]", false},
+		{"[1, 'a'] == ['a', 1]", false},
+		{"join(names, ', ')", "tyson, paul"},
+		{"max(xs[0], xs[-1])", int64(50)},
+		{"[xs[0], len(names)]", []any{int64(10), int64(2)}},
+		{"matrix[1:][0]", []any{3., 4.}},
+		{"matrix[-1][1]", 4.},
+		{"mixed[3][0]", int64(1)},
+		{"mixed[2:]", []any{true, []any{int64(1)}}},
+		{"names[1:][0]", "paul"},
+		{"empty[:]", []any{}},
+	}
+
+	ns := namespace{"name": "tyson"}
+	for key, val := range lists {
+		ns[key] = val
+	}
+
+	for _, test := range tests {
+		val := Calc(test.program, ns)
+		if !reflect.DeepEqual(val, test.expected) {
+			t.Errorf("%s: got %#v, want %#v", test.program, val, test.expected)
+		}
+	}
+}
+
+func Test_list_errors(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected error
+	}{
+		{
+			program:  "xs[5]",
+			expected: &IndexError{Code: CodeIndexOutOfRange, Pos: Pos{Offset: 2, Line: 1, Column: 3}, Index: 5, Len: 5},
+		},
+		{
+			program:  "xs[-6]",
+			expected: &IndexError{Code: CodeIndexOutOfRange, Pos: Pos{Offset: 2, Line: 1, Column: 3}, Index: -6, Len: 5},
+		},
+		{
+			program: "xs[0.5]",
+			expected: &TypeError{
+				Code:  CodeNotInteger,
+				Pos:   Pos{Offset: 2, Line: 1, Column: 3},
+				Op:    "[]",
+				Types: []string{"number"},
+			},
+		},
+		{
+			program: "xs['a']",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 2, Line: 1, Column: 3},
+				Op:    "[]",
+				Types: []string{"string"},
+			},
+		},
+		{
+			program: "xs[1:'a']",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 2, Line: 1, Column: 3},
+				Op:    "[:]",
+				Types: []string{"string"},
+			},
+		},
+		{
+			program: "16[0]",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 2, Line: 1, Column: 3},
+				Op:    "[]",
+				Types: []string{"number"},
+			},
+		},
+		{
+			program: "xs < xs",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 3, Line: 1, Column: 4},
+				Op:    "<",
+				Types: []string{"list", "list"},
+			},
+		},
+		{
+			program: "xs[1 2]",
+			expected: &SyntaxError{
+				Code: CodeUnexpectedToken,
+				Pos:  Pos{Offset: 5, Line: 1, Column: 6},
+				Msg:  "ожидалось ':' | ']'",
+			},
+		},
+		{
+			program: "[1, 2",
+			expected: &SyntaxError{
+				Code: CodeUnexpectedToken,
+				Pos:  Pos{Offset: 5, Line: 1, Column: 6},
+				Msg:  "ожидалось ',' | ']'",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, ok := Calc(test.program, lists).(error)
+		if !ok {
+			t.Errorf("%q: expected error", test.program)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%q: got %#v, want %#v", test.program, got, test.expected)
+		}
+	}
+
+	var target *IndexError
+	if err, _ := Calc("xs[10]", lists).(error); !errors.As(err, &target) || err.Error() != "1:3: индекс 10 вне диапазона [0, 5)" {
+		t.Errorf("xs[10]: got %v", err)
+	}
+}
+
+// срез Go под индексом не копируется, а []any с приведёнными элементами
+// не копируется и при чтении целиком.
+func Test_list_lazy(t *testing.T) {
+	big := make([]int, 100_000)
+	big[0] = 7
+	items := make([]any, 100_000)
+	for i := range items {
+		items[i] = int64(i)
+	}
+	ns := namespace{"big": big, "items": items}
+
+	tests := []struct {
+		program  string
+		expected any
+		allocs   float64
+	}{
+		{"big[0]", int64(7), 1},
+		{"big[-1]", int64(0), 2},
+		{"big[:2]", []any{int64(7), int64(0)}, 4},
+		{"items[1]", int64(1), 1},
+		{"len(items)", int64(100_000), 20},
+	}
+
+	for _, test := range tests {
+		p := MustCompile(test.program)
+		val, err := p.Eval(ns)
+		if err != nil || !reflect.DeepEqual(val, test.expected) {
+			t.Errorf("%s: got %#v, %v, want %#v", test.program, val, err, test.expected)
+		}
+
+		allocs := testing.AllocsPerRun(10, func() { _, _ = p.Eval(ns) })
+		if allocs > test.allocs {
+			t.Errorf("%s: %v allocations, want at most %v", test.program, allocs, test.allocs)
+		}
+	}
+
+	if val := Calc("items", ns).([]any); &val[0] != &items[0] {
+		t.Error("items: []any copied")
+	}
+}
@@ -0,0 +1,49 @@
+package calc
+
+/*
+&& и || вычисляются сокращённо: правый операнд не вычисляется, если результат
+известен по левому, поэтому user != null && user.age > 18 не обращается
+к полю null. оба операнда должны быть bool, иначе возвращается TypeError
+с типом того операнда, который не подошёл.
+*/
+
+func (n *binaryNode) logical(namespace Namespace) any {
+	left := n.left.exec(namespace)
+	if _, ok := left.(error); ok {
+		return left
+	}
+
+	l, err := n.operand(left)
+	if err != nil {
+		return err
+	}
+
+	if n.decided(l) {
+		return l
+	}
+
+	right := n.right.exec(namespace)
+	if _, ok := right.(error); ok {
+		return right
+	}
+
+	r, err := n.operand(right)
+	if err != nil {
+		return err
+	}
+	return r
+}
+
+// operand проверяет, что операнд && или || — bool.
+func (n *binaryNode) operand(val any) (bool, error) {
+	b, ok := val.(bool)
+	if !ok {
+		return false, newTypeError(CodeInvalidOperand, n.pos, opName(n.op), val)
+	}
+	return b, nil
+}
+
+// decided сообщает, что результат равен левому операнду и правый не нужен.
+func (n *binaryNode) decided(left bool) bool {
+	return left == (n.op == orOp)
+}
@@ -0,0 +1,143 @@
+package calc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_logical(t *testing.T) {
+	var calls int
+	ns := namespace{
+		"yes":  true,
+		"no":   false,
+		"user": nil,
+		"age":  20,
+		"touch": func() bool {
+			calls++
+			return true
+		},
+	}
+
+	tests := []struct {
+		program  string
+		expected any
+		calls    int
+	}{
+		{"yes && yes", true, 0},
+		{"yes && no", false, 0},
+		{"no || yes", true, 0},
+		{"no || no", false, 0},
+		{"no && missing", false, 0},
+		{"yes || missing", true, 0},
+		{"no && 1", false, 0},
+		{"yes || 'a'", true, 0},
+		{"no && touch()", false, 0},
+		{"yes || touch()", true, 0},
+		{"yes && touch()", true, 1},
+		{"no || touch()", true, 1},
+		{"user != null && user.age > 18", false, 0},
+		{"user == null || user.age > 18", true, 0},
+		{"age > 18 && age < 30 || missing", true, 0},
+		{"no && missing || yes", true, 0},
+		{"(no && missing) ?? 1", false, 0},
+		{"1 > 2 && missing", false, 0},
+		{"[1, 2] == [1, 2] || missing", true, 0},
+	}
+
+	for _, test := range tests {
+		calls = 0
+
+		val := Calc(test.program, ns)
+		if !reflect.DeepEqual(val, test.expected) {
+			t.Errorf("%s: got %#v, want %#v", test.program, val, test.expected)
+		}
+
+		if calls != test.calls {
+			t.Errorf("%s: touch called %d times, want %d", test.program, calls, test.calls)
+		}
+	}
+}
+
+func Test_logical_errors(t *testing.T) {
+	ns := namespace{"yes": true, "no": false, "age": 20, "name": "tyson"}
+
+	tests := []struct {
+		program  string
+		expected error
+	}{
+		{
+			program: "yes && 1",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 4, Line: 1, Column: 5},
+				Op:    "&&",
+				Types: []string{"number"},
+			},
+		},
+		{
+			program: "age && yes",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 4, Line: 1, Column: 5},
+				Op:    "&&",
+				Types: []string{"number"},
+			},
+		},
+		{
+			program: "no || name",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 3, Line: 1, Column: 4},
+				Op:    "||",
+				Types: []string{"string"},
+			},
+		},
+		{
+			program: "name || yes",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 5, Line: 1, Column: 6},
+				Op:    "||",
+				Types: []string{"string"},
+			},
+		},
+		{
+			program: "yes && missing",
+			expected: &UnknownIdentifierError{
+				Code: CodeUnknownIdentifier,
+				Pos:  Pos{Offset: 7, Line: 1, Column: 8},
+				Name: "missing",
+			},
+		},
+		{
+			program: "1 && missing",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 2, Line: 1, Column: 3},
+				Op:    "&&",
+				Types: []string{"number"},
+			},
+		},
+		{
+			program: "1 < 2 && 'a'",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 6, Line: 1, Column: 7},
+				Op:    "&&",
+				Types: []string{"string"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, ok := Calc(test.program, ns).(error)
+		if !ok {
+			t.Errorf("%q: expected error", test.program)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%q: got %#v, want %#v", test.program, got, test.expected)
+		}
+	}
+}
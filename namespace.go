@@ -0,0 +1,204 @@
+package calc
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// MapNamespace — Namespace поверх словаря.
+type MapNamespace map[string]any
+
+func (n MapNamespace) Get(key string) (any, bool) {
+	val, ok := n[key]
+	return val, ok
+}
+
+// Func превращает функцию поиска в Namespace.
+type Func func(key string) (any, bool)
+
+func (f Func) Get(key string) (any, bool) { return f(key) }
+
+type chain []Namespace
+
+func (c chain) Get(key string) (any, bool) {
+	for _, ns := range c {
+		if val, ok := ns.Get(key); ok {
+			return val, true
+		}
+	}
+	return nil, false
+}
+
+// Chain возвращает Namespace, который ищет ключ по очереди во всех ns
+// и берёт значение из первого, где ключ есть. nil в ns пропускаются.
+func Chain(ns ...Namespace) Namespace {
+	c := make(chain, 0, len(ns))
+	for _, n := range ns {
+		if n == nil {
+			continue
+		}
+
+		//вложенные цепочки разворачиваются, чтобы не тратить лишний вызов на уровень
+		if inner, ok := n.(chain); ok {
+			c = append(c, inner...)
+			continue
+		}
+
+		c = append(c, n)
+	}
+	return c
+}
+
+// Overlay возвращает base, в котором значения из values переопределяют одноимённые.
+// удобно для подстановки значений на время одного вызова Eval.
+func Overlay(base Namespace, values map[string]any) Namespace {
+	return Chain(MapNamespace(values), base)
+}
+
+type lenient struct{ Namespace }
+
+func (n lenient) Get(key string) (any, bool) {
+	if n.Namespace != nil {
+		if val, ok := n.Namespace.Get(key); ok {
+			return val, true
+		}
+	}
+	return nil, true
+}
+
+// Lenient возвращает Namespace, в котором неизвестные идентификаторы равны null,
+// а не приводят к ошибке. ns может быть nil.
+func Lenient(ns Namespace) Namespace {
+	return lenient{ns}
+}
+
+/*
+NamespaceOf возвращает Namespace поверх структуры Go. доступны экспортируемые поля,
+имя можно переопределить тегом `calc:"name"`, а тег `calc:"-"` скрывает поле.
+поля встроенных структур (в том числе по указателю) доступны как собственные,
+как в encoding/json: поле с меньшей глубиной вложенности скрывает более глубокое,
+а одноимённые поля на одной глубине не доступны вовсе.
+если по пути к полю встречается nil-указатель, поле считается отсутствующим.
+v должен быть структурой или указателем на структуру, иначе NamespaceOf паникует.
+*/
+func NamespaceOf(v any) Namespace {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return structNamespace{fields: structFields(rv.Type().Elem())}
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("calc: NamespaceOf: ожидалась структура, получено %T", v))
+	}
+
+	return structNamespace{rv, structFields(rv.Type())}
+}
+
+type structNamespace struct {
+	v      reflect.Value
+	fields map[string][]int
+}
+
+func (n structNamespace) Get(key string) (any, bool) {
+	index, ok := n.fields[key]
+	if !ok || !n.v.IsValid() {
+		return nil, false
+	}
+
+	field, err := n.v.FieldByIndexErr(index)
+	if err != nil {
+		return nil, false
+	}
+
+	return field.Interface(), true
+}
+
+// fieldCache хранит имена полей для каждого типа структуры: reflect.Type -> map[string][]int.
+var fieldCache sync.Map
+
+// structFields возвращает индексы доступных полей структуры по их именам в выражениях.
+func structFields(t reflect.Type) map[string][]int {
+	if fields, ok := fieldCache.Load(t); ok {
+		return fields.(map[string][]int)
+	}
+
+	fields, _ := fieldCache.LoadOrStore(t, collectFields(t))
+	return fields.(map[string][]int)
+}
+
+func collectFields(t reflect.Type) map[string][]int {
+	type level struct {
+		typ   reflect.Type
+		index []int
+	}
+
+	fields := make(map[string][]int)
+	hidden := make(map[string]bool) //одноимённые поля на одной глубине
+	visited := map[reflect.Type]bool{t: true}
+
+	for current := []level{{t, nil}}; len(current) > 0; {
+		var next []level
+		found := make(map[string]int) //сколько раз имя встретилось на этой глубине
+		candidates := make(map[string][]int)
+
+		for _, l := range current {
+			for i := range l.typ.NumField() {
+				field := l.typ.Field(i)
+				index := append(append([]int{}, l.index...), i)
+
+				tag := field.Tag.Get("calc")
+				if tag == "-" {
+					continue
+				}
+
+				if field.Anonymous && tag == "" {
+					typ := field.Type
+					if typ.Kind() == reflect.Pointer {
+						typ = typ.Elem()
+					}
+
+					if typ.Kind() == reflect.Struct {
+						if !visited[typ] {
+							visited[typ] = true
+							next = append(next, level{typ, index})
+						}
+						continue
+					}
+				}
+
+				if !field.IsExported() {
+					continue
+				}
+
+				name := field.Name
+				if tag != "" {
+					name = tag
+				}
+
+				found[name]++
+				candidates[name] = index
+			}
+		}
+
+		for name, index := range candidates {
+			if _, ok := fields[name]; ok || hidden[name] {
+				continue
+			}
+
+			if found[name] > 1 {
+				hidden[name] = true
+				continue
+			}
+
+			fields[name] = index
+		}
+
+		current = next
+	}
+
+	return fields
+}
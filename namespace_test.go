@@ -0,0 +1,203 @@
+package calc
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type Base struct {
+	ID      int
+	Tenant  string `calc:"tenant"`
+	Deleted bool
+}
+
+type Owner struct {
+	ID   int
+	Name string
+}
+
+type Product struct {
+	Base
+	*Owner   `calc:"owner"`
+	Title    string `calc:"title"`
+	Price    float64
+	Secret   string `calc:"-"`
+	Stock    *int
+	Discount *float64
+	Label    *string `calc:"label"`
+	Deleted  bool    `calc:"is_deleted"`
+	Dims     struct {
+		Width  float64 `calc:"w"`
+		Height float64 `calc:"h"`
+	} `calc:"dims"`
+	internal string
+}
+
+func Test_NamespaceOf(t *testing.T) {
+	stock, label := 16, "хит"
+	product := &Product{
+		Base:     Base{ID: 7, Tenant: "acme", Deleted: true},
+		Owner:    &Owner{ID: 1, Name: "tyson"},
+		Title:    "чайник",
+		Price:    19.5,
+		Secret:   "x",
+		Stock:    &stock,
+		Label:    &label,
+		Deleted:  false,
+		internal: "y",
+	}
+	product.Dims.Width, product.Dims.Height = 2, 3
+
+	tests := []struct {
+		program  string
+		expected any
+	}{
+		{"ID", int64(7)},
+		{"tenant", "acme"},
+		{"title + ' ' + owner.Name", "чайник tyson"},
+		{"Price * 2", 39.},
+		{"is_deleted", false},
+		{"dims.w * dims.h", 6.},
+		{"owner.ID", int64(1)},
+		{"Deleted && !is_deleted", true},
+		{"Stock * 2", int64(32)},
+		{"Discount ?? 0", int64(0)},
+		{"label + '!'", "хит!"},
+	}
+
+	ns := NamespaceOf(product)
+	for _, test := range tests {
+		val := Calc(test.program, ns)
+		if !reflect.DeepEqual(val, test.expected) {
+			t.Errorf("%s: got %#v, want %#v", test.program, val, test.expected)
+		}
+	}
+
+	if val, ok := ns.Get("Stock"); !ok || *val.(*int) != 16 {
+		t.Errorf("Stock: got %v %v", val, ok)
+	}
+
+	for _, key := range []string{"Secret", "internal", "Title", "Tenant", "Owner", "Base", "Name", "missing"} {
+		if val, ok := ns.Get(key); ok {
+			t.Errorf("%s: expected missing, got %v", key, val)
+		}
+	}
+
+	if _, ok := NamespaceOf(Product{}).Get("ID"); !ok {
+		t.Errorf("ID: expected field from value struct")
+	}
+
+	if _, ok := NamespaceOf((*Product)(nil)).Get("ID"); ok {
+		t.Errorf("ID: expected missing field for nil pointer")
+	}
+
+	if val := Calc("tenant", NamespaceOf(&Product{Owner: nil})); val != "" {
+		t.Errorf("tenant: got %v", val)
+	}
+}
+
+func Test_NamespaceOf_ambiguous(t *testing.T) {
+	type A struct{ X, Y int }
+	type B struct{ X, Z int }
+	type C struct {
+		A
+		B
+		Z string
+	}
+
+	ns := NamespaceOf(C{A{1, 2}, B{3, 4}, "z"})
+
+	if _, ok := ns.Get("X"); ok {
+		t.Errorf("X: expected ambiguous field to be hidden")
+	}
+
+	if val, _ := ns.Get("Y"); val != 2 {
+		t.Errorf("Y: got %v", val)
+	}
+
+	if val, _ := ns.Get("Z"); val != "z" {
+		t.Errorf("Z: got %v", val)
+	}
+}
+
+func Test_NamespaceOf_panic(t *testing.T) {
+	for _, v := range []any{16, nil} {
+		func() {
+			defer func() {
+				msg, _ := recover().(string)
+				if !strings.HasPrefix(msg, "calc: NamespaceOf: ожидалась структура") {
+					t.Errorf("NamespaceOf(%v): expected panic, got %q", v, msg)
+				}
+			}()
+
+			NamespaceOf(v)
+		}()
+	}
+}
+
+func Test_Chain(t *testing.T) {
+	tenant := MapNamespace{"currency": "USD", "tax": 0.2, "discount": 0}
+	request := MapNamespace{"discount": 5}
+	lookups := 0
+	fallback := Func(func(key string) (any, bool) {
+		lookups++
+		if key == "region" {
+			return "eu", true
+		}
+		return nil, false
+	})
+
+	ns := Chain(request, nil, Chain(tenant, fallback))
+
+	tests := []struct {
+		program  string
+		expected any
+	}{
+		{"discount", int64(5)},
+		{"currency", "USD"},
+		{"region", "eu"},
+		{"100 * (1 + tax) - discount", 115.},
+	}
+
+	for _, test := range tests {
+		val := Calc(test.program, ns)
+		if !reflect.DeepEqual(val, test.expected) {
+			t.Errorf("%s: got %#v, want %#v", test.program, val, test.expected)
+		}
+	}
+
+	if _, ok := ns.Get("missing"); ok {
+		t.Errorf("missing: expected not found")
+	}
+
+	if len(ns.(chain)) != 3 {
+		t.Errorf("expected flattened chain, got %d namespaces", len(ns.(chain)))
+	}
+
+	if lookups != 2 {
+		t.Errorf("fallback: got %d lookups, want 2", lookups)
+	}
+}
+
+func Test_Overlay(t *testing.T) {
+	defaults := NamespaceOf(&Product{Title: "чайник", Price: 19.5})
+	p := MustCompile("title + ': ' + format('%.2f', Price)")
+
+	tests := []struct {
+		ns       Namespace
+		expected any
+	}{
+		{defaults, "чайник: 19.50"},
+		{Overlay(defaults, map[string]any{"Price": 10}), "чайник: 10.00"},
+		{Overlay(defaults, map[string]any{"title": "кружка"}), "кружка: 19.50"},
+		{Overlay(defaults, nil), "чайник: 19.50"},
+	}
+
+	for _, test := range tests {
+		val, err := p.Eval(test.ns)
+		if err != nil || val != test.expected {
+			t.Errorf("got %v %v, want %v", val, err, test.expected)
+		}
+	}
+}
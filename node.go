@@ -1,9 +1,10 @@
 package calc
 
 import (
-	"errors"
 	"math"
+	"math/big"
 	"reflect"
+	"strings"
 )
 
 type Namespace interface {
@@ -12,10 +13,29 @@ type Namespace interface {
 
 type node interface{ exec(namespace Namespace) any }
 
-type numNode struct{ val float64 }
+type numNode struct {
+	val float64
+	pos Pos
+}
 
 func (n *numNode) exec(_ Namespace) any { return n.val }
 
+// intNode — целочисленный литерал.
+type intNode struct {
+	val int64
+	pos Pos
+}
+
+func (n *intNode) exec(_ Namespace) any { return n.val }
+
+// decNode — числовой литерал в DecimalMode.
+type decNode struct {
+	val Decimal
+	pos Pos
+}
+
+func (n *decNode) exec(_ Namespace) any { return n.val }
+
 const (
 	addOp uint8 = iota + 1
 	subOp
@@ -30,11 +50,52 @@ const (
 	moreEqOp
 	andOp
 	orOp
+	notOp
+	modOp
+	floorDivOp
+	bitAndOp
+	bitOrOp
+	bitXorOp
+	bitNotOp
+	shlOp
+	shrOp
+	inOp
+	notInOp
 )
 
+var opNames = [...]string{
+	addOp:      "+",
+	subOp:      "-",
+	mulOp:      "*",
+	divOp:      "/",
+	powOp:      "**",
+	eqOp:       "==",
+	notEqOp:    "!=",
+	lessOp:     "<",
+	lessEqOp:   "<=",
+	moreOp:     ">",
+	moreEqOp:   ">=",
+	andOp:      "&&",
+	orOp:       "||",
+	notOp:      "!",
+	modOp:      "%",
+	floorDivOp: "//",
+	bitAndOp:   "&",
+	bitOrOp:    "|",
+	bitXorOp:   "^",
+	bitNotOp:   "~",
+	shlOp:      "<<",
+	shrOp:      ">>",
+	inOp:       "in",
+	notInOp:    "not in",
+}
+
+func opName(op uint8) string { return opNames[op] }
+
 type unaryNode struct {
 	op  uint8
 	val node
+	pos Pos
 }
 
 func (n *unaryNode) exec(namespace Namespace) any {
@@ -43,15 +104,66 @@ func (n *unaryNode) exec(namespace Namespace) any {
 		return val
 	}
 
+	return n.apply(val)
+}
+
+// apply применяет оператор к вычисленному операнду.
+func (n *unaryNode) apply(val any) any {
+	switch v := val.(type) {
+	case int64:
+		return n.integer(v)
+	case Decimal:
+		return n.decimal(v)
+	}
+
 	switch n.op {
+	case addOp:
+		if _, ok := val.(float64); !ok {
+			return newTypeError(CodeInvalidOperand, n.pos, opName(n.op), val)
+		}
+		return val
+
 	case subOp:
 		if _, ok := val.(float64); !ok {
-			return errors.New("")
+			return newTypeError(CodeInvalidOperand, n.pos, opName(n.op), val)
 		}
 		return -val.(float64)
 
+	case notOp:
+		if _, ok := val.(bool); !ok {
+			return newTypeError(CodeInvalidOperand, n.pos, opName(n.op), val)
+		}
+		return !val.(bool)
+
+	case bitNotOp:
+		if _, ok := val.(float64); !ok {
+			return newTypeError(CodeInvalidOperand, n.pos, opName(n.op), val)
+		}
+		i, ok := toInt(val.(float64))
+		if !ok {
+			return newTypeError(CodeNotInteger, n.pos, opName(n.op), val)
+		}
+		return float64(^i)
+
 	default:
-		return errors.New("")
+		return newTypeError(CodeInvalidOperand, n.pos, opName(n.op), val)
+	}
+}
+
+func (n *unaryNode) decimal(val Decimal) any {
+	switch n.op {
+	case addOp:
+		return val
+	case subOp:
+		return val.neg()
+	case bitNotOp:
+		i, ok := val.bigInt()
+		if !ok {
+			return newTypeError(CodeNotInteger, n.pos, opName(n.op), val)
+		}
+		return Decimal{i.Not(i), 0}
+	default:
+		return newTypeError(CodeInvalidOperand, n.pos, opName(n.op), val)
 	}
 }
 
@@ -59,9 +171,15 @@ type binaryNode struct {
 	op    uint8
 	left  node
 	right node
+	pos   Pos             //позиция оператора
+	dec   *decimalContext //не nil в DecimalMode
 }
 
 func (n *binaryNode) exec(namespace Namespace) any {
+	if n.op == andOp || n.op == orOp {
+		return n.logical(namespace)
+	}
+
 	left := n.left.exec(namespace)
 	if _, ok := left.(error); ok {
 		return left
@@ -72,8 +190,38 @@ func (n *binaryNode) exec(namespace Namespace) any {
 		return right
 	}
 
+	return n.apply(left, right)
+}
+
+// apply применяет оператор к вычисленным операндам.
+func (n *binaryNode) apply(left, right any) any {
+	if n.op == inOp || n.op == notInOp {
+		return n.contains(left, right)
+	}
+
+	//null равен только null, сравнение с ним не ошибка
+	if (n.op == eqOp || n.op == notEqOp) && (left == nil || right == nil) {
+		return (left == right) == (n.op == eqOp)
+	}
+
+	if isNumber(left) && isNumber(right) {
+		_, l := left.(Decimal)
+		_, r := right.(Decimal)
+		if l || r || n.dec != nil {
+			return n.decimal(left, right)
+		}
+
+		if l, ok := left.(int64); ok {
+			if r, ok := right.(int64); ok {
+				return n.integer(l, r)
+			}
+		}
+
+		left, right = promote(left, right)
+	}
+
 	if reflect.TypeOf(left) != reflect.TypeOf(right) {
-		return errors.New("")
+		return newTypeError(CodeMismatchedTypes, n.pos, opName(n.op), left, right)
 	}
 
 	switch n.op {
@@ -85,8 +233,12 @@ func (n *binaryNode) exec(namespace Namespace) any {
 			return left.(bool) == right.(bool)
 		case string:
 			return left.(string) == right.(string)
+		case []any:
+			return equal(left, right)
+		case map[string]any:
+			return equal(left, right)
 		default:
-			return errors.New("")
+			return newTypeError(CodeInvalidOperand, n.pos, opName(n.op), left, right)
 		}
 	case notEqOp:
 		switch left.(type) {
@@ -96,8 +248,12 @@ func (n *binaryNode) exec(namespace Namespace) any {
 			return left.(bool) != right.(bool)
 		case string:
 			return left.(string) != right.(string)
+		case []any:
+			return !equal(left, right)
+		case map[string]any:
+			return !equal(left, right)
 		default:
-			return errors.New("")
+			return newTypeError(CodeInvalidOperand, n.pos, opName(n.op), left, right)
 		}
 	case lessOp:
 		switch left.(type) {
@@ -106,7 +262,7 @@ func (n *binaryNode) exec(namespace Namespace) any {
 		case string:
 			return left.(string) < right.(string)
 		default:
-			return errors.New("")
+			return newTypeError(CodeInvalidOperand, n.pos, opName(n.op), left, right)
 		}
 	case lessEqOp:
 		switch left.(type) {
@@ -115,7 +271,7 @@ func (n *binaryNode) exec(namespace Namespace) any {
 		case string:
 			return left.(string) <= right.(string)
 		default:
-			return errors.New("")
+			return newTypeError(CodeInvalidOperand, n.pos, opName(n.op), left, right)
 		}
 	case moreOp:
 		switch left.(type) {
@@ -124,7 +280,7 @@ func (n *binaryNode) exec(namespace Namespace) any {
 		case string:
 			return left.(string) > right.(string)
 		default:
-			return errors.New("")
+			return newTypeError(CodeInvalidOperand, n.pos, opName(n.op), left, right)
 		}
 	case moreEqOp:
 		switch left.(type) {
@@ -133,20 +289,8 @@ func (n *binaryNode) exec(namespace Namespace) any {
 		case string:
 			return left.(string) >= right.(string)
 		default:
-			return errors.New("")
+			return newTypeError(CodeInvalidOperand, n.pos, opName(n.op), left, right)
 		}
-
-	case andOp:
-		if _, ok := left.(bool); !ok {
-			return errors.New("")
-		}
-		return left.(bool) && right.(bool)
-
-	case orOp:
-		if _, ok := left.(bool); !ok {
-			return errors.New("")
-		}
-		return left.(bool) || right.(bool)
 	}
 
 	if n.op == addOp {
@@ -156,12 +300,12 @@ func (n *binaryNode) exec(namespace Namespace) any {
 		case string:
 			return left + right.(string)
 		default:
-			return errors.New("")
+			return newTypeError(CodeInvalidOperand, n.pos, opName(n.op), left, right)
 		}
 	}
 
 	if _, ok := left.(float64); !ok {
-		return errors.New("")
+		return newTypeError(CodeInvalidOperand, n.pos, opName(n.op), left, right)
 	}
 
 	switch n.op {
@@ -170,12 +314,106 @@ func (n *binaryNode) exec(namespace Namespace) any {
 	case mulOp:
 		return left.(float64) * right.(float64)
 	case divOp:
+		if right.(float64) == 0 {
+			return &DivisionError{Code: CodeDivisionByZero, Pos: n.pos, Op: opName(n.op)}
+		}
 		return left.(float64) / right.(float64)
 	case powOp:
 		return math.Pow(left.(float64), right.(float64))
+	case modOp, floorDivOp:
+		if right.(float64) == 0 {
+			return &DivisionError{Code: CodeDivisionByZero, Pos: n.pos, Op: opName(n.op)}
+		}
+		if n.op == modOp {
+			return floorMod(left.(float64), right.(float64))
+		}
+		return math.Floor(left.(float64) / right.(float64))
+	case bitAndOp, bitOrOp, bitXorOp, shlOp, shrOp:
+		return n.bitwise(left.(float64), right.(float64))
+	default:
+		return newTypeError(CodeInvalidOperand, n.pos, opName(n.op), left, right)
+	}
+}
+
+/*
+contains проверяет вхождение для in и not in:
+элемент в списке (сравнение как в ==), ключ в словаре или поле в структуре,
+подстрока в строке.
+*/
+func (n *binaryNode) contains(left, right any) any {
+	var found bool
+
+	switch r := right.(type) {
+	case []any:
+		for _, item := range r {
+			if equal(left, item) {
+				found = true
+				break
+			}
+		}
+
+	case string:
+		l, ok := left.(string)
+		if !ok {
+			return newTypeError(CodeInvalidOperand, n.pos, opName(n.op), left, right)
+		}
+		found = strings.Contains(r, l)
+
 	default:
-		return errors.New("")
+		if !isObject(right) {
+			return newTypeError(CodeInvalidOperand, n.pos, opName(n.op), left, right)
+		}
+
+		_, status := member(right, left)
+		if status == memberBadKey {
+			return newTypeError(CodeInvalidOperand, n.pos, opName(n.op), left, right)
+		}
+		found = status == memberFound
+	}
+
+	if n.op == notInOp {
+		return !found
+	}
+	return found
+}
+
+// bitwise выполняет побитовые операции над целыми числами, дробные числа — ошибка.
+func (n *binaryNode) bitwise(left, right float64) any {
+	l, ok := toInt(left)
+	if !ok {
+		return newTypeError(CodeNotInteger, n.pos, opName(n.op), left, right)
+	}
+
+	r, ok := toInt(right)
+	if !ok {
+		return newTypeError(CodeNotInteger, n.pos, opName(n.op), left, right)
+	}
+
+	switch n.op {
+	case bitAndOp:
+		return float64(l & r)
+	case bitOrOp:
+		return float64(l | r)
+	case bitXorOp:
+		return float64(l ^ r)
+	}
+
+	if r < 0 {
+		return newTypeError(CodeNegativeShift, n.pos, opName(n.op), left, right)
+	}
+
+	if n.op == shlOp {
+		return float64(l << r)
+	}
+	return float64(l >> r)
+}
+
+// toInt возвращает целое значение числа, если у него нет дробной части.
+func toInt(f float64) (int64, bool) {
+	if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, false
 	}
+	return int64(f), true
 }
 
 type errNode struct{ err error }
@@ -191,6 +429,7 @@ type ternaryNode struct {
 	cond    node
 	ifTrue  node
 	ifFalse node
+	pos     Pos //позиция '?'
 }
 
 func (n *ternaryNode) exec(namespace Namespace) any {
@@ -199,51 +438,183 @@ func (n *ternaryNode) exec(namespace Namespace) any {
 		return cond
 	}
 
-	if _, ok := cond.(bool); !ok {
-		return errors.New("")
+	ok, err := n.branch(cond)
+	if err != nil {
+		return err
 	}
 
-	if cond.(bool) {
+	if ok {
 		return n.ifTrue.exec(namespace)
 	}
 	return n.ifFalse.exec(namespace)
 }
 
-type strNode struct{ val string }
+// branch проверяет, что условие — bool, и возвращает его.
+func (n *ternaryNode) branch(cond any) (bool, error) {
+	ok, isBool := cond.(bool)
+	if !isBool {
+		return false, newTypeError(CodeInvalidOperand, n.pos, "?:", cond)
+	}
+	return ok, nil
+}
+
+type strNode struct {
+	val string
+	pos Pos
+}
 
 func (n *strNode) exec(_ Namespace) any { return n.val }
 
-type identNode struct{ val string }
+type identNode struct {
+	val string
+	pos Pos
+}
 
 func (n *identNode) exec(namespace Namespace) any {
+	if namespace == nil {
+		return &UnknownIdentifierError{Code: CodeUnknownIdentifier, Pos: n.pos, Name: n.val}
+	}
+
 	val, ok := namespace.Get(n.val)
 	if !ok {
-		return errors.New("")
+		return &UnknownIdentifierError{Code: CodeUnknownIdentifier, Pos: n.pos, Name: n.val}
+	}
+	return normalize(val)
+}
+
+// sequence возвращает значение под индексом или срезом. срезы и массивы Go,
+// кроме []any, не копируются в список: xs[0] и xs[1:3] читают через reflect
+// только нужные элементы.
+func (n *identNode) sequence(namespace Namespace) any {
+	if namespace == nil {
+		return n.exec(namespace)
 	}
+
+	val, ok := namespace.Get(n.val)
+	if !ok {
+		return n.exec(namespace)
+	}
+	if _, ok := val.([]any); !ok && goSequence(val) {
+		return val
+	}
+	return normalize(val)
+}
+
+// sequence вычисляет значение под индексом или срезом, см. identNode.sequence.
+func sequence(n node, namespace Namespace) any {
+	if ident, ok := n.(*identNode); ok {
+		return ident.sequence(namespace)
+	}
+	return n.exec(namespace)
+}
+
+// normalize приводит значение из Go к типам, с которыми работают узлы.
+// целые числа становятся int64, а uint64 больше math.MaxInt64 — точным Decimal.
+// указатели на значения, кроме структур, разыменовываются, а nil-указатели и nil-словари становятся null.
+func normalize(val any) any {
 	switch v := val.(type) {
 	case int:
-		val = float64(v)
+		val = int64(v)
 	case int8:
-		val = float64(v)
+		val = int64(v)
 	case int16:
-		val = float64(v)
+		val = int64(v)
 	case int32:
-		val = float64(v)
-	case int64:
-		val = float64(v)
+		val = int64(v)
 	case uint:
-		val = float64(v)
+		val = normalizeUint(uint64(v))
 	case uint8:
-		val = float64(v)
+		val = int64(v)
 	case uint16:
-		val = float64(v)
+		val = int64(v)
 	case uint32:
-		val = float64(v)
+		val = int64(v)
 	case uint64:
-		val = float64(v)
+		val = normalizeUint(v)
 	case float32:
 		val = float64(v)
+	default:
+		rv := reflect.ValueOf(val)
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			//именованные типы вроде type ID int64
+			val = rv.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			val = normalizeUint(rv.Uint())
+		case reflect.Float32, reflect.Float64:
+			val = rv.Float()
+		case reflect.Slice, reflect.Array:
+			val = toList(rv)
+		case reflect.Pointer:
+			//указатели на структуры остаются объектами, остальные разыменовываются: *float64 — число
+			if rv.IsNil() {
+				val = nil
+			} else if elem := rv.Elem(); elem.Kind() != reflect.Struct || elem.Type() == decimalType {
+				val = normalize(elem.Interface())
+			}
+		case reflect.Map, reflect.Func, reflect.Chan, reflect.Interface:
+			if rv.IsNil() {
+				val = nil
+			}
+		}
 	}
 
 	return val
 }
+
+func normalizeUint(u uint64) any {
+	if u > math.MaxInt64 {
+		return Decimal{new(big.Int).SetUint64(u), 0}
+	}
+	return int64(u)
+}
+
+// nodePos возвращает позицию узла в исходном тексте.
+func nodePos(n node) Pos {
+	switch n := n.(type) {
+	case *numNode:
+		return n.pos
+	case *strNode:
+		return n.pos
+	case *identNode:
+		return n.pos
+	case *unaryNode:
+		return n.pos
+	case *binaryNode:
+		return n.pos
+	case *ternaryNode:
+		return n.pos
+	case *callNode:
+		return n.pos
+	case *listNode:
+		return n.pos
+	case *indexNode:
+		return n.pos
+	case *sliceNode:
+		return n.pos
+	case *mapNode:
+		return n.pos
+	case *memberNode:
+		return n.pos
+	case *letNode:
+		return n.pos
+	case *seqNode:
+		return nodePos(n.first)
+	case *lambdaNode:
+		return n.pos
+	case *matchNode:
+		return n.pos
+	case *nullNode:
+		return n.pos
+	case *decNode:
+		return n.pos
+	case *intNode:
+		return n.pos
+	case *coalesceNode:
+		return n.pos
+	case *constNode:
+		return n.pos
+	default:
+		return Pos{}
+	}
+}
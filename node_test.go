@@ -10,34 +10,49 @@ func Test_node(t *testing.T) {
 		n        node
 		expected any
 	}{
-		{n: &numNode{16.}, expected: 16.},
-		{n: &numNode{32.}, expected: 32.},
-		{n: &numNode{64.64}, expected: 64.64},
-		{n: &unaryNode{subOp, &numNode{32.}}, expected: -32.},
-		{n: &unaryNode{subOp, &numNode{64.64}}, expected: -64.64},
+		{n: &numNode{val: 16.}, expected: 16.},
+		{n: &numNode{val: 32.}, expected: 32.},
+		{n: &numNode{val: 64.64}, expected: 64.64},
+		{n: &unaryNode{op: subOp, val: &numNode{val: 32.}}, expected: -32.},
+		{n: &unaryNode{op: subOp, val: &numNode{val: 64.64}}, expected: -64.64},
+		{n: &unaryNode{op: addOp, val: &numNode{val: 64.64}}, expected: 64.64},
+		{
+			n: &unaryNode{
+				op:  subOp,
+				val: &unaryNode{op: subOp, val: &numNode{val: 32.}},
+			},
+			expected: 32.,
+		},
+		{
+			n: &unaryNode{
+				op:  notOp,
+				val: &binaryNode{op: lessOp, left: &numNode{val: 16.}, right: &numNode{val: 32.}},
+			},
+			expected: false,
+		},
 		{
 			n: &binaryNode{
-				op: addOp, left: &numNode{32.}, right: &numNode{64.64}},
+				op: addOp, left: &numNode{val: 32.}, right: &numNode{val: 64.64}},
 			expected: 96.64,
 		},
 		{
 			n: &binaryNode{
-				op: subOp, left: &numNode{32.}, right: &numNode{16.}},
+				op: subOp, left: &numNode{val: 32.}, right: &numNode{val: 16.}},
 			expected: 16.,
 		},
 		{
 			n: &binaryNode{
-				op: mulOp, left: &numNode{16.}, right: &numNode{64.}},
+				op: mulOp, left: &numNode{val: 16.}, right: &numNode{val: 64.}},
 			expected: 1024.,
 		},
 		{
 			n: &binaryNode{
-				op: divOp, left: &numNode{64.}, right: &numNode{16.}},
+				op: divOp, left: &numNode{val: 64.}, right: &numNode{val: 16.}},
 			expected: 4.,
 		},
 		{
 			n: &binaryNode{
-				op: powOp, left: &numNode{16.}, right: &numNode{4.}},
+				op: powOp, left: &numNode{val: 16.}, right: &numNode{val: 4.}},
 			expected: 65536.,
 		},
 		{
@@ -45,10 +60,10 @@ func Test_node(t *testing.T) {
 				op: addOp,
 				left: &binaryNode{
 					op:    mulOp,
-					left:  &numNode{16.},
-					right: &numNode{64.},
+					left:  &numNode{val: 16.},
+					right: &numNode{val: 64.},
 				},
-				right: &numNode{32.},
+				right: &numNode{val: 32.},
 			},
 			expected: 1056.,
 		},
@@ -57,10 +72,10 @@ func Test_node(t *testing.T) {
 				op: mulOp,
 				left: &binaryNode{
 					op:    addOp,
-					left:  &numNode{16.},
-					right: &numNode{64.},
+					left:  &numNode{val: 16.},
+					right: &numNode{val: 64.},
 				},
-				right: &numNode{32.},
+				right: &numNode{val: 32.},
 			},
 			expected: 2560.,
 		},
@@ -69,21 +84,21 @@ func Test_node(t *testing.T) {
 				op: subOp,
 				left: &binaryNode{
 					op:   addOp,
-					left: &numNode{16.},
+					left: &numNode{val: 16.},
 					right: &binaryNode{
 						op:   mulOp,
-						left: &numNode{64.},
+						left: &numNode{val: 64.},
 						right: &binaryNode{
 							op:    powOp,
-							left:  &numNode{32.},
-							right: &numNode{2.},
+							left:  &numNode{val: 32.},
+							right: &numNode{val: 2.},
 						},
 					},
 				},
 				right: &binaryNode{
 					op:    divOp,
-					left:  &numNode{16.},
-					right: &numNode{64.},
+					left:  &numNode{val: 16.},
+					right: &numNode{val: 64.},
 				},
 			},
 			expected: 65551.75,
@@ -92,48 +107,48 @@ func Test_node(t *testing.T) {
 			expected: false,
 			n: &binaryNode{
 				op:    eqOp,
-				left:  &numNode{16.},
-				right: &numNode{32.},
+				left:  &numNode{val: 16.},
+				right: &numNode{val: 32.},
 			},
 		},
 		{
 			expected: true,
 			n: &binaryNode{
 				op:    notEqOp,
-				left:  &numNode{16.},
-				right: &numNode{32.},
+				left:  &numNode{val: 16.},
+				right: &numNode{val: 32.},
 			},
 		},
 		{
 			expected: true,
 			n: &binaryNode{
 				op:    lessEqOp,
-				left:  &numNode{16.},
-				right: &numNode{32.},
+				left:  &numNode{val: 16.},
+				right: &numNode{val: 32.},
 			},
 		},
 		{
 			expected: false,
 			n: &binaryNode{
 				op:    moreEqOp,
-				left:  &numNode{16.},
-				right: &numNode{32.},
+				left:  &numNode{val: 16.},
+				right: &numNode{val: 32.},
 			},
 		},
 		{
 			expected: false,
 			n: &binaryNode{
 				op:    moreOp,
-				left:  &numNode{16.},
-				right: &numNode{32.},
+				left:  &numNode{val: 16.},
+				right: &numNode{val: 32.},
 			},
 		},
 		{
 			expected: true,
 			n: &binaryNode{
 				op:    lessOp,
-				left:  &numNode{16.},
-				right: &numNode{32.},
+				left:  &numNode{val: 16.},
+				right: &numNode{val: 32.},
 			},
 		},
 	}
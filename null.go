@@ -0,0 +1,60 @@
+package calc
+
+import "errors"
+
+/*
+null — отсутствие значения. его дают литерал null, nil из Namespace и функций
+(а также nil-указатели и nil-словари) и obj?.name для отсутствующего поля.
+null можно сравнивать с чем угодно через == и !=, остальные операторы
+возвращают ошибку типа.
+*/
+
+type nullNode struct {
+	pos Pos
+}
+
+func (n *nullNode) exec(Namespace) any { return nil }
+
+// coalesceNode — a ?? b: значение a, если оно есть и не равно null, иначе b.
+type coalesceNode struct {
+	left  node
+	right node
+	pos   Pos //позиция '??'
+}
+
+func (n *coalesceNode) exec(namespace Namespace) any {
+	left := n.left.exec(namespace)
+	if err, ok := left.(error); ok {
+		if !isMissing(n.left, err) {
+			return left
+		}
+		left = nil
+	}
+
+	if left != nil {
+		return left
+	}
+
+	return n.right.exec(namespace)
+}
+
+/*
+isMissing сообщает, что err — отсутствие идентификатора или поля в самом n.
+ошибки внутри других выражений не подменяются: в f(x) ?? 0 неизвестный x
+остаётся ошибкой, а в x ?? 0 и user.address.city ?? "" — нет.
+*/
+func isMissing(n node, err error) bool {
+	switch n.(type) {
+	case *identNode, *memberNode, *indexNode:
+	default:
+		return false
+	}
+
+	var ident *UnknownIdentifierError
+	if errors.As(err, &ident) {
+		return ident.Code == CodeUnknownIdentifier
+	}
+
+	var member *MemberError
+	return errors.As(err, &member)
+}
@@ -0,0 +1,166 @@
+package calc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_null(t *testing.T) {
+	var nothing *user
+
+	ns := Chain(objects, namespace{
+		"nothing": nothing,
+		"none":    nil,
+		"price":   10,
+		"empty":   map[string]any{"note": nil},
+	})
+
+	tests := []struct {
+		program  string
+		expected any
+	}{
+		{"null", nil},
+		{"none", nil},
+		{"nothing", nil},
+		{"null == null", true},
+		{"none == null", true},
+		{"nothing != null", false},
+		{"price == null", false},
+		{"null != 'a'", true},
+		{"[1, null] == [1, null]", true},
+		{"null in [1, null]", true},
+		{"empty.note", nil},
+		{"{'a': null}", map[string]any{"a": nil}},
+		{"none ?? 5", int64(5)},
+		{"price ?? 5", int64(10)},
+		{"missing ?? 'default'", "default"},
+		{"missing ?? none ?? 3", int64(3)},
+		{"user.Address.Zip ?? 'нет'", "нет"},
+		{"config.limits.min ?? config.limits.max", int64(64)},
+		{"config['limits']['min'] ?? 0", int64(0)},
+		{"discount ?? 1 + 2", int64(3)},
+		{"price > 5 || price < 0 ?? 1", true},
+		{"(none ?? 2) * 3", int64(6)},
+		{"user?.Name", "tyson"},
+		{"user?.Manager?.Manager?.Name", nil},
+		{"user?.Manager?.Manager?.Name ?? 'никто'", "никто"},
+		{"nothing?.Name", nil},
+		{"none?.a?.b", nil},
+		{"config?.limits?.min", nil},
+		{"config?.limits?.max", int64(64)},
+		{"price > 5 ?.5 : 1", .5},
+		{"max(none ?? 1, 2)", int64(2)},
+		{"let x = null; x ?? 7", int64(7)},
+	}
+
+	for _, test := range tests {
+		val := Calc(test.program, ns)
+		if !reflect.DeepEqual(val, test.expected) {
+			t.Errorf("%s: got %#v, want %#v", test.program, val, test.expected)
+		}
+	}
+}
+
+func Test_null_errors(t *testing.T) {
+	ns := namespace{"none": nil, "price": 10}
+
+	tests := []struct {
+		program  string
+		expected error
+	}{
+		{
+			program: "null + 1",
+			expected: &TypeError{
+				Code:  CodeMismatchedTypes,
+				Pos:   Pos{Offset: 5, Line: 1, Column: 6},
+				Op:    "+",
+				Types: []string{"null", "number"},
+			},
+		},
+		{
+			program: "none.a",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 4, Line: 1, Column: 5},
+				Op:    ".",
+				Types: []string{"null"},
+			},
+		},
+		{
+			program: "len(missing) ?? 0",
+			expected: &UnknownIdentifierError{
+				Code: CodeUnknownIdentifier,
+				Pos:  Pos{Offset: 4, Line: 1, Column: 5},
+				Name: "missing",
+			},
+		},
+		{
+			program: "price + missing ?? 0",
+			expected: &UnknownIdentifierError{
+				Code: CodeUnknownIdentifier,
+				Pos:  Pos{Offset: 8, Line: 1, Column: 9},
+				Name: "missing",
+			},
+		},
+		{
+			program: "price?.1",
+			expected: &SyntaxError{
+				Code: CodeUnexpectedToken,
+				Pos:  Pos{Offset: 8, Line: 1, Column: 9},
+				Msg:  "ожидалось ':'",
+			},
+		},
+		{
+			program: "price ?? ",
+			expected: &SyntaxError{
+				Code: CodeUnexpectedToken,
+				Pos:  Pos{Offset: 9, Line: 1, Column: 10},
+				Msg:  "ожидалось число | '('",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, ok := Calc(test.program, ns).(error)
+		if !ok {
+			t.Errorf("%q: expected error", test.program)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%q: got %#v, want %#v", test.program, got, test.expected)
+		}
+	}
+}
+
+func Test_Lenient(t *testing.T) {
+	ns := Lenient(namespace{"price": 10})
+
+	tests := []struct {
+		program  string
+		expected any
+	}{
+		{"price", int64(10)},
+		{"missing", nil},
+		{"missing == null", true},
+		{"missing ?? price", int64(10)},
+		{"missing?.a?.b", nil},
+		{"max(price, 20)", int64(20)},
+		{"let f = (x) => x ?? 0; f(missing)", int64(0)},
+	}
+
+	for _, test := range tests {
+		val := Calc(test.program, ns)
+		if !reflect.DeepEqual(val, test.expected) {
+			t.Errorf("%s: got %#v, want %#v", test.program, val, test.expected)
+		}
+	}
+
+	if val := Calc("nothing", Lenient(nil)); val != nil {
+		t.Errorf("Lenient(nil): got %#v", val)
+	}
+
+	if _, ok := Calc("unknown(1)", ns).(*UnknownIdentifierError); !ok {
+		t.Errorf("unknown function must fail in Lenient")
+	}
+}
@@ -0,0 +1,187 @@
+package calc
+
+import "reflect"
+
+/*
+объекты — это map[string]any из литералов {"a": 1, b: 2}, а также любые словари
+и структуры Go из Namespace. они не копируются в normalize: поля читаются
+через reflect только при обращении user.address.city или user["address"].
+поля структур доступны по тем же правилам, что и в NamespaceOf, с учётом тегов calc.
+*/
+
+type mapNode struct {
+	keys []string
+	vals []node
+	pos  Pos
+}
+
+func (n *mapNode) exec(namespace Namespace) any {
+	obj := make(map[string]any, len(n.keys))
+	for i, key := range n.keys {
+		val := n.vals[i].exec(namespace)
+		if _, ok := val.(error); ok {
+			return val
+		}
+		obj[key] = val
+	}
+	return obj
+}
+
+type memberNode struct {
+	val      node
+	name     string
+	path     string //путь до val вида user.address, если его можно вычислить при разборе
+	optional bool   //obj?.name
+	pos      Pos    //позиция '.'
+}
+
+/*
+obj?.name возвращает null, если obj равен null или поля name нет.
+каждое звено цепочки проверяется отдельно: в user?.address.city
+address не может быть null, а в user?.address?.city — может.
+*/
+func (n *memberNode) exec(namespace Namespace) any {
+	val := n.val.exec(namespace)
+	if _, ok := val.(error); ok {
+		return val
+	}
+
+	return n.apply(val)
+}
+
+// apply возвращает поле вычисленного объекта.
+func (n *memberNode) apply(val any) any {
+	if n.optional && val == nil {
+		return nil
+	}
+
+	res, status := member(val, n.name)
+	switch status {
+	case memberFound:
+		return res
+	case memberMissing:
+		if n.optional {
+			return nil
+		}
+		return &MemberError{Code: CodeUnknownMember, Pos: n.pos, Path: n.path, Name: n.name}
+	default:
+		return newTypeError(CodeInvalidOperand, n.pos, ".", val)
+	}
+}
+
+// memberPath возвращает путь вида user.address для цепочки идентификаторов и полей.
+func memberPath(n node) string {
+	switch n := n.(type) {
+	case *identNode:
+		return n.val
+	case *memberNode:
+		if n.path == "" {
+			return ""
+		}
+		return n.path + "." + n.name
+	default:
+		return ""
+	}
+}
+
+const (
+	memberFound uint8 = iota + 1
+	memberMissing
+	memberBadKey    //тип ключа не подходит к словарю
+	memberNotObject //у значения не может быть полей
+)
+
+// member возвращает значение словаря по ключу или поле структуры по имени.
+func member(val any, key any) (any, uint8) {
+	if _, ok := val.(Decimal); ok {
+		return nil, memberNotObject
+	}
+
+	if obj, ok := val.(map[string]any); ok {
+		k, ok := key.(string)
+		if !ok {
+			return nil, memberBadKey
+		}
+
+		res, ok := obj[k]
+		if !ok {
+			return nil, memberMissing
+		}
+		return normalize(res), memberFound
+	}
+
+	v := reflect.ValueOf(val)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, memberNotObject
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		k, ok := convertArg(key, v.Type().Key())
+		if !ok {
+			return nil, memberBadKey
+		}
+
+		res := v.MapIndex(k)
+		if !res.IsValid() {
+			return nil, memberMissing
+		}
+		return normalize(res.Interface()), memberFound
+
+	case reflect.Struct:
+		name, ok := key.(string)
+		if !ok {
+			return nil, memberBadKey
+		}
+
+		index, ok := structFields(v.Type())[name]
+		if !ok {
+			return nil, memberMissing
+		}
+
+		res, err := v.FieldByIndexErr(index)
+		if err != nil {
+			//поле встроенной структуры, на которую указывает nil
+			return nil, memberMissing
+		}
+		return normalize(res.Interface()), memberFound
+
+	default:
+		return nil, memberNotObject
+	}
+}
+
+// isObject сообщает, есть ли у значения поля или ключи.
+func isObject(val any) bool {
+	switch val.(type) {
+	case map[string]any:
+		return true
+	case Decimal:
+		return false
+	}
+
+	v := reflect.ValueOf(val)
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v.Kind() == reflect.Map || v.Kind() == reflect.Struct
+}
+
+// equalMap сравнивает словари из литералов поэлементно.
+func equalMap(l, r map[string]any) bool {
+	if len(l) != len(r) {
+		return false
+	}
+
+	for key, val := range l {
+		other, ok := r[key]
+		if !ok || !equal(val, other) {
+			return false
+		}
+	}
+
+	return true
+}
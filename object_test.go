@@ -0,0 +1,168 @@
+package calc
+
+import (
+	"reflect"
+	"testing"
+)
+
+type address struct {
+	City   string
+	Street string
+	zip    string
+}
+
+type Audit struct {
+	CreatedBy string
+}
+
+type user struct {
+	*Audit
+	Name    string
+	Age     int
+	Address address
+	Manager *user
+	Tags    []string
+	Meta    map[string]int
+}
+
+var objects = namespace{
+	"user": user{
+		Audit:   &Audit{CreatedBy: "root"},
+		Name:    "tyson",
+		Age:     32,
+		Address: address{City: "Москва", zip: "101000"},
+		Manager: &user{Name: "paul"},
+		Tags:    []string{"admin", "staff"},
+		Meta:    map[string]int{"visits": 16},
+	},
+	"orphan": &user{Name: "mike"},
+	"config": map[string]any{
+		"limits": map[string]any{"max": 64},
+		"hosts":  []any{map[string]any{"name": "a"}},
+	},
+	"codes": map[int]string{404: "not found"},
+}
+
+func Test_object(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected any
+	}{
+		{"{}", map[string]any{}},
+		{"{'a': 1, b: 'два', `c d`: [1]}", map[string]any{"a": int64(1), "b": "два", "c d": []any{int64(1)}}},
+		{"{'a': {'b': 2}}.a.b", int64(2)},
+		{"{'a': 1}['a']", int64(1)},
+		{"user.Name", "tyson"},
+		{"user.Age + 1", int64(33)},
+		{"user.Address.City", "Москва"},
+		{"user['Address']['City']", "Москва"},
+		{"user.Manager.Name", "paul"},
+		{"user.CreatedBy", "root"},
+		{"user.Tags[0]", "admin"},
+		{"user.Tags", []any{"admin", "staff"}},
+		{"user.Meta.visits", int64(16)},
+		{"orphan.Name", "mike"},
+		{"config.limits.max * 2", int64(128)},
+		{"config['limits'].max", int64(64)},
+		{"config.hosts[0].name", "a"},
+		{"codes[404]", "not found"},
+		{"{'a': [1, 2]} == {'a': [1, 2]}", true},
+		{"{'a': 1} == {'a': 2}", false},
+		{"{'a': 1} != {'b': 1}", true},
+		{"len(user.Name)", int64(5)},
+	}
+
+	for _, test := range tests {
+		val := Calc(test.program, objects)
+		if !reflect.DeepEqual(val, test.expected) {
+			t.Errorf("%s: got %#v, want %#v", test.program, val, test.expected)
+		}
+	}
+}
+
+func Test_object_errors(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected error
+	}{
+		{
+			program:  "user.Address.Zip",
+			expected: &MemberError{Code: CodeUnknownMember, Pos: Pos{Offset: 12, Line: 1, Column: 13}, Path: "user.Address", Name: "Zip"},
+		},
+		{
+			program:  "user.Address.zip",
+			expected: &MemberError{Code: CodeUnknownMember, Pos: Pos{Offset: 12, Line: 1, Column: 13}, Path: "user.Address", Name: "zip"},
+		},
+		{
+			program:  "orphan.CreatedBy",
+			expected: &MemberError{Code: CodeUnknownMember, Pos: Pos{Offset: 6, Line: 1, Column: 7}, Path: "orphan", Name: "CreatedBy"},
+		},
+		{
+			program:  "config.hosts[0].port",
+			expected: &MemberError{Code: CodeUnknownMember, Pos: Pos{Offset: 15, Line: 1, Column: 16}, Name: "port"},
+		},
+		{
+			program:  "config['limits']['min']",
+			expected: &MemberError{Code: CodeUnknownMember, Pos: Pos{Offset: 16, Line: 1, Column: 17}, Name: "min"},
+		},
+		{
+			program:  "config.limits['min']",
+			expected: &MemberError{Code: CodeUnknownMember, Pos: Pos{Offset: 13, Line: 1, Column: 14}, Path: "config.limits", Name: "min"},
+		},
+		{
+			program: "user.Manager.Manager.Name",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 20, Line: 1, Column: 21},
+				Op:    ".",
+				Types: []string{"null"},
+			},
+		},
+		{
+			program: "user.Name.First",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 9, Line: 1, Column: 10},
+				Op:    ".",
+				Types: []string{"string"},
+			},
+		},
+		{
+			program: "config[1]",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 6, Line: 1, Column: 7},
+				Op:    "[]",
+				Types: []string{"map", "number"},
+			},
+		},
+		{
+			program: "user.1",
+			expected: &SyntaxError{
+				Code: CodeUnexpectedToken,
+				Pos:  Pos{Offset: 4, Line: 1, Column: 5},
+				Msg:  "не удалось разобрать выражение",
+			},
+		},
+		{
+			program: "user.",
+			expected: &SyntaxError{
+				Code: CodeUnexpectedToken,
+				Pos:  Pos{Offset: 5, Line: 1, Column: 6},
+				Msg:  "ожидалось имя поля",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, ok := Calc(test.program, objects).(error)
+		if !ok {
+			t.Errorf("%q: expected error", test.program)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%q: got %#v, want %#v", test.program, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package calc
+
+/*
+fold вычисляет при компиляции поддеревья, состоящие только из литералов:
+(2 + 3) * 5 становится одним constNode, а в 1 > 2 ? a : b остаётся только b.
+ошибка при вычислении такого поддерева, например 1 + "a", возвращается из Compile,
+только если поддерево выполняется всегда. ветви ?: с неизвестным условием, правая
+часть ??, && и ||, тело лямбды и аргументы функций не из builtins могут
+не выполниться: flag ? 1 : 1 / 0 равно 1, поэтому там узел с ошибкой
+остаётся как есть и вернёт её при выполнении.
+ветви ?: и ??, которые никогда не выполнятся, не вычисляются и ошибок не дают.
+вызовы функций не вычисляются: Namespace может переопределить встроенную функцию.
+результатом свёртки может быть только число, строка, bool или null —
+списки и словари изменяемы и не должны разделяться между вызовами Eval.
+*/
+
+// constNode — значение, вычисленное при компиляции.
+type constNode struct {
+	val any
+	pos Pos
+}
+
+func (n *constNode) exec(_ Namespace) any { return n.val }
+
+// isConst сообщает, известно ли значение узла при компиляции.
+func isConst(n node) bool {
+	switch n.(type) {
+	case *numNode, *intNode, *decNode, *strNode, *nullNode, *constNode:
+		return true
+	default:
+		return false
+	}
+}
+
+// folder сворачивает константы. lazy — поддерево может не выполниться,
+// тогда ошибки свёртки не возвращаются, а узел остаётся несвёрнутым.
+type folder struct {
+	lazy bool
+}
+
+func fold(n node) (node, error) {
+	return folder{}.fold(n)
+}
+
+// fail возвращает ошибку свёртки n или, если n может не выполниться, сам n.
+func (f folder) fail(n node, err error) (node, error) {
+	if f.lazy {
+		return n, nil
+	}
+	return nil, err
+}
+
+// eval вычисляет узел с константными операндами и заменяет его на constNode.
+func (f folder) eval(n node) (node, error) {
+	val := n.exec(nil)
+	if err, ok := val.(error); ok {
+		return f.fail(n, err)
+	}
+
+	switch val.(type) {
+	case nil, bool, string, int64, float64, Decimal:
+		return &constNode{val, nodePos(n)}, nil
+	default:
+		return n, nil
+	}
+}
+
+func (f folder) fold(n node) (node, error) {
+	var err error
+	lazy := folder{lazy: true}
+
+	switch n := n.(type) {
+	case *unaryNode:
+		if n.val, err = f.fold(n.val); err != nil {
+			return nil, err
+		}
+		if isConst(n.val) {
+			return f.eval(n)
+		}
+
+	case *binaryNode:
+		if n.left, err = f.fold(n.left); err != nil {
+			return nil, err
+		}
+		logic := n.op == andOp || n.op == orOp
+		//1 > 2 && x — правая часть не вычисляется
+		if logic && isConst(n.left) {
+			left, err := n.operand(n.left.exec(nil))
+			if err != nil {
+				return f.fail(n, err)
+			}
+			if n.decided(left) {
+				return &constNode{left, n.pos}, nil
+			}
+		}
+		if logic && !isConst(n.left) {
+			if n.right, err = lazy.fold(n.right); err != nil {
+				return nil, err
+			}
+		} else if n.right, err = f.fold(n.right); err != nil {
+			return nil, err
+		}
+		if isConst(n.left) && isConst(n.right) {
+			return f.eval(n)
+		}
+
+	case *matchNode:
+		if n.val, err = f.fold(n.val); err != nil {
+			return nil, err
+		}
+		if n.pattern, err = f.fold(n.pattern); err != nil {
+			return nil, err
+		}
+		if isConst(n.val) && isConst(n.pattern) {
+			return f.eval(n)
+		}
+
+	case *ternaryNode:
+		if n.cond, err = f.fold(n.cond); err != nil {
+			return nil, err
+		}
+
+		if isConst(n.cond) {
+			cond := n.cond.exec(nil)
+			if _, ok := cond.(bool); !ok {
+				return f.fail(n, newTypeError(CodeInvalidOperand, n.pos, "?:", cond))
+			}
+			if cond.(bool) {
+				return f.fold(n.ifTrue)
+			}
+			return f.fold(n.ifFalse)
+		}
+
+		if n.ifTrue, err = lazy.fold(n.ifTrue); err != nil {
+			return nil, err
+		}
+		if n.ifFalse, err = lazy.fold(n.ifFalse); err != nil {
+			return nil, err
+		}
+
+	case *coalesceNode:
+		if n.left, err = f.fold(n.left); err != nil {
+			return nil, err
+		}
+
+		if isConst(n.left) {
+			if n.left.exec(nil) != nil {
+				return n.left, nil
+			}
+			return f.fold(n.right)
+		}
+
+		if n.right, err = lazy.fold(n.right); err != nil {
+			return nil, err
+		}
+
+	case *listNode:
+		for i := range n.items {
+			if n.items[i], err = f.fold(n.items[i]); err != nil {
+				return nil, err
+			}
+		}
+
+	case *mapNode:
+		for i := range n.vals {
+			if n.vals[i], err = f.fold(n.vals[i]); err != nil {
+				return nil, err
+			}
+		}
+
+	case *memberNode:
+		if n.val, err = f.fold(n.val); err != nil {
+			return nil, err
+		}
+
+	case *indexNode:
+		if n.val, err = f.fold(n.val); err != nil {
+			return nil, err
+		}
+		if n.index, err = f.fold(n.index); err != nil {
+			return nil, err
+		}
+		if isConst(n.val) && isConst(n.index) {
+			return f.eval(n)
+		}
+
+	case *sliceNode:
+		if n.val, err = f.fold(n.val); err != nil {
+			return nil, err
+		}
+		if n.from != nil {
+			if n.from, err = f.fold(n.from); err != nil {
+				return nil, err
+			}
+		}
+		if n.to != nil {
+			if n.to, err = f.fold(n.to); err != nil {
+				return nil, err
+			}
+		}
+		if isConst(n.val) && (n.from == nil || isConst(n.from)) && (n.to == nil || isConst(n.to)) {
+			return f.eval(n)
+		}
+
+	case *callNode:
+		//функция ищется раньше аргументов: в nofn(1 + 'a') первой должна быть
+		//ошибка неизвестной функции, поэтому аргументы свёртываются без ошибок,
+		//если функция не встроенная и может не найтись
+		args := f
+		if _, ok := builtins[n.name]; !ok {
+			args = lazy
+		}
+		for i := range n.args {
+			if n.args[i], err = args.fold(n.args[i]); err != nil {
+				return nil, err
+			}
+		}
+
+	case *lambdaNode:
+		//map([], x => 1 + 'a') не вызывает лямбду
+		if n.body, err = lazy.fold(n.body); err != nil {
+			return nil, err
+		}
+
+	case *letNode:
+		if n.val, err = f.fold(n.val); err != nil {
+			return nil, err
+		}
+		if n.body, err = f.fold(n.body); err != nil {
+			return nil, err
+		}
+
+	case *seqNode:
+		if n.first, err = f.fold(n.first); err != nil {
+			return nil, err
+		}
+		if n.rest, err = f.fold(n.rest); err != nil {
+			return nil, err
+		}
+	}
+
+	return n, nil
+}
@@ -0,0 +1,187 @@
+package calc
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_fold(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected node
+	}{
+		{"(2 + 3) * 5", &constNode{val: int64(25)}},
+		{"-(1.5 * 2)", &constNode{val: -3.}},
+		{"'a' + 'b' == 'ab'", &constNode{val: true}},
+		{"'abc'[1:]", &constNode{val: "bc"}},
+		{"'abc' =~ '^a'", &constNode{val: true}},
+		{"null ?? 1 + 1", &constNode{val: int64(2)}},
+		{"'a' ?? x", &strNode{val: "a"}},
+		{"1 > 2 ? x : 'b'", &strNode{val: "b"}},
+		{"1 < 2 ? x : 1 / 0", &identNode{val: "x"}},
+		{
+			"x + 2 * 3",
+			&binaryNode{op: addOp, left: &identNode{val: "x"}, right: &constNode{val: int64(6)}},
+		},
+		{
+			"x ? 1 + 1 : 2 ** 2",
+			&ternaryNode{
+				cond:    &identNode{val: "x"},
+				ifTrue:  &constNode{val: int64(2)},
+				ifFalse: &constNode{val: int64(4)},
+			},
+		},
+		{
+			"[1 + 1, x]",
+			&listNode{items: []node{&constNode{val: int64(2)}, &identNode{val: "x"}}},
+		},
+		{
+			"len('ab' + 'c')",
+			&callNode{name: "len", args: []node{&constNode{val: "abc"}}},
+		},
+		{
+			"map(xs, x => x * (1 + 1))",
+			&callNode{name: "map", args: []node{
+				&identNode{val: "xs"},
+				&lambdaNode{params: []string{"x"}, body: &binaryNode{
+					op:    mulOp,
+					left:  &identNode{val: "x"},
+					right: &constNode{val: int64(2)},
+				}},
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		p, err := Compile(test.program)
+		if err != nil {
+			t.Errorf("%s: %v", test.program, err)
+			continue
+		}
+
+		if got := clearPos(p.root); !reflect.DeepEqual(got, clearPos(test.expected)) {
+			t.Errorf("%s: got %#v, want %#v", test.program, got, test.expected)
+		}
+	}
+}
+
+func Test_fold_errors(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected error
+	}{
+		{
+			program: `x + (1 + "a")`,
+			expected: &TypeError{
+				Code:  CodeMismatchedTypes,
+				Pos:   Pos{Offset: 7, Line: 1, Column: 8},
+				Op:    "+",
+				Types: []string{"number", "string"},
+			},
+		},
+		{
+			program: "[x, 1 / 0]",
+			expected: &DivisionError{
+				Code: CodeDivisionByZero,
+				Pos:  Pos{Offset: 6, Line: 1, Column: 7},
+				Op:   "/",
+			},
+		},
+		{
+			program: "1 ? x : 2",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 2, Line: 1, Column: 3},
+				Op:    "?:",
+				Types: []string{"number"},
+			},
+		},
+		{
+			program: "'abc'[5]",
+			expected: &IndexError{
+				Code:  CodeIndexOutOfRange,
+				Pos:   Pos{Offset: 5, Line: 1, Column: 6},
+				Index: 5,
+				Len:   3,
+			},
+		},
+		{
+			program: "9223372036854775807 + 1",
+			expected: &OverflowError{
+				Code: CodeIntegerOverflow,
+				Pos:  Pos{Offset: 20, Line: 1, Column: 21},
+				Op:   "+",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		_, err := Compile(test.program)
+		if !reflect.DeepEqual(err, test.expected) {
+			t.Errorf("%q: got %#v, want %#v", test.program, err, test.expected)
+		}
+	}
+}
+
+// ошибки в поддеревьях, которые могут не выполниться, возвращаются только при выполнении.
+func Test_fold_lazy(t *testing.T) {
+	ns := namespace{"flag": true, "xs": []any{}, "x": nil}
+
+	tests := []struct {
+		program  string
+		expected any
+	}{
+		{"flag ? 1 : 1 / 0", int64(1)},
+		{"flag ?? 1 / 0", true},
+		{"flag || 1 + 'a' == 2", true},
+		{"!flag && (1 ? 2 : 3)", false},
+		{"map(xs, x => 1 + 'a')", []any{}},
+		{"x ?? 1 ?? [1 / 0]", int64(1)},
+	}
+
+	for _, test := range tests {
+		p, err := Compile(test.program)
+		if err != nil {
+			t.Errorf("%s: %v", test.program, err)
+			continue
+		}
+
+		if val, err := p.Eval(ns); err != nil || !reflect.DeepEqual(val, test.expected) {
+			t.Errorf("%s: got %#v, %v, want %#v", test.program, val, err, test.expected)
+		}
+	}
+
+	//ветвь, которая выполнилась, возвращает ту же ошибку, что и без свёртки
+	p := MustCompile("flag ? 1 / 0 : 1")
+	_, err := p.Eval(ns)
+	expected := &DivisionError{Code: CodeDivisionByZero, Pos: Pos{Offset: 9, Line: 1, Column: 10}, Op: "/"}
+	if !reflect.DeepEqual(err, expected) {
+		t.Errorf("flag ? 1 / 0 : 1: got %#v, want %#v", err, expected)
+	}
+}
+
+// аргументы неизвестной функции не дают ошибок Compile: функция ищется первой.
+func Test_fold_call(t *testing.T) {
+	p, err := Compile("nofn(1 + 'a')")
+	if err != nil {
+		t.Fatalf("nofn(1 + 'a'): %v", err)
+	}
+
+	_, err = p.Eval(nil)
+	expected := &UnknownIdentifierError{Code: CodeUnknownFunction, Pos: Pos{Offset: 0, Line: 1, Column: 1}, Name: "nofn"}
+	if !reflect.DeepEqual(err, expected) {
+		t.Errorf("nofn(1 + 'a'): got %#v, want %#v", err, expected)
+	}
+
+	//функция из Namespace найдена, ошибка — в аргументе
+	var target *TypeError
+	if _, err = p.Eval(namespace{"nofn": func(x any) any { return x }}); !errors.As(err, &target) || target.Code != CodeMismatchedTypes {
+		t.Errorf("nofn(1 + 'a') with nofn: got %v", err)
+	}
+
+	//встроенная функция находится всегда
+	if _, err = Compile("len(1 + 'a')"); !errors.As(err, &target) {
+		t.Errorf("len(1 + 'a'): got %v, want TypeError", err)
+	}
+}
@@ -1,11 +1,16 @@
 package calc
 
 import (
-	"errors"
+	"regexp"
 	"strconv"
+	"strings"
 )
 
-type parser struct{ tok *tokenizer }
+type parser struct {
+	tok    *tokenizer
+	dec    *decimalContext //не nil в DecimalMode
+	parens map[node]Pos    //позиции '(' вокруг подвыражений, заполняется только для Parse
+}
 
 func newParser(data string) *parser {
 	return &parser{tok: newTokenizer(data)}
@@ -19,6 +24,11 @@ parse считывает токен, если токен соответству
 */
 type parse func() node
 
+// error создаёт узел-ошибку в позиции текущего токена.
+func (p *parser) error(code Code, msg string) node {
+	return &errNode{&SyntaxError{Code: code, Pos: p.tok.currentPos(), Msg: msg}}
+}
+
 func (p *parser) parse() node {
 	p.tok.nextTok()
 
@@ -26,61 +36,398 @@ func (p *parser) parse() node {
 		return nil
 	}
 
-	//parse с самым низким приоритетом
-	n := p.parse8()
+	n := p.parseStatements()
 	if isErr(n) {
 		return n
 	}
 
+	if tok := p.tok.currentTok(); tok.typ == errTyp {
+		return p.error(CodeInvalidToken, tok.val)
+	}
+
 	if p.tok.currentTok().typ != eofTyp {
-		return &errNode{errors.New("не удалось разобрать выражение")}
+		return p.error(CodeUnexpectedToken, "не удалось разобрать выражение")
 	}
 
 	return n
 }
 
+/*
+parseStatements разбирает программу из инструкций через ';':
+let name = expr; ...; expr. результат программы — значение последней инструкции,
+переменная из let видна во всех инструкциях после неё и скрывает одноимённое
+значение из Namespace. ';' после последней инструкции допускается.
+*/
+func (p *parser) parseStatements() node {
+	tok, pos := p.tok.currentTok(), p.tok.currentPos()
+
+	if isKeyword(tok, "let") {
+		p.tok.nextTok()
+
+		name := p.tok.currentTok()
+		if name.typ != identTyp {
+			return p.error(CodeUnexpectedToken, "ожидалось имя переменной")
+		}
+
+		p.tok.nextTok()
+
+		if p.tok.currentTok().typ != assignTyp {
+			return p.error(CodeUnexpectedToken, "ожидалось '='")
+		}
+
+		p.tok.nextTok()
+
+		//parse с самым низким приоритетом
+		val := p.parse13()
+		if isErr(val) {
+			return val
+		}
+
+		if p.tok.currentTok().typ != semicolonTyp {
+			return p.error(CodeUnexpectedToken, "ожидалось ';'")
+		}
+
+		p.tok.nextTok()
+
+		if p.tok.currentTok().typ == eofTyp {
+			return p.error(CodeUnexpectedToken, "ожидалось выражение после let")
+		}
+
+		body := p.parseStatements()
+		if isErr(body) {
+			return body
+		}
+
+		return &letNode{name.val, val, body, pos}
+	}
+
+	//parse с самым низким приоритетом
+	n := p.parse13()
+	if isErr(n) {
+		return n
+	}
+
+	if p.tok.currentTok().typ != semicolonTyp {
+		return n
+	}
+
+	p.tok.nextTok()
+
+	if p.tok.currentTok().typ == eofTyp {
+		return n
+	}
+
+	rest := p.parseStatements()
+	if isErr(rest) {
+		return rest
+	}
+
+	return &seqNode{n, rest}
+}
+
+// isKeyword сообщает, является ли токен ключевым словом word (in, not, let).
+func isKeyword(tok token, word string) bool {
+	return tok.typ == identTyp && tok.val == word
+}
+
+// parse0 разбирает операнд и следующие за ним индексы, срезы и обращения к полям:
+// xs[0], xs[1:3][0], user.address["city"].
 func (p *parser) parse0() node {
-	tok := p.tok.currentTok()
+	n := p.parseOperand()
+	if isErr(n) {
+		return n
+	}
 
-	if tok.typ == numTyp {
+	for {
+		switch p.tok.currentTok().typ {
+		case lBracketTyp:
+			n = p.parseIndex(n)
+		case dotTyp, optionalDotTyp:
+			n = p.parseMember(n)
+		default:
+			return n
+		}
+
+		if isErr(n) {
+			return n
+		}
+	}
+}
+
+func (p *parser) parseOperand() node {
+	tok, pos := p.tok.currentTok(), p.tok.currentPos()
+
+	if tok.typ == errTyp {
+		return p.error(CodeInvalidToken, tok.val)
+	}
+
+	if tok.typ == numTyp && p.dec != nil {
+		val, err := ParseDecimal(tok.val)
+		if err != nil {
+			return p.error(CodeInvalidNumber, "некорректное число "+tok.val)
+		}
+		p.tok.nextTok()
+		return &decNode{val, pos}
+	}
+
+	if tok.typ == numTyp && !strings.Contains(tok.val, ".") {
+		val, err := strconv.ParseInt(tok.val, 10, 64)
+		if err != nil {
+			return p.error(CodeInvalidNumber, "целое число "+tok.val+" не помещается в int64")
+		}
 		p.tok.nextTok()
+		return &intNode{val, pos}
+	}
+
+	if tok.typ == numTyp {
 		val, err := strconv.ParseFloat(tok.val, 64)
 		if err != nil {
-			return &errNode{err}
+			return p.error(CodeInvalidNumber, "некорректное число "+tok.val)
 		}
-		return &numNode{val}
+		p.tok.nextTok()
+		return &numNode{val, pos}
 	}
 
 	if tok.typ == strTyp {
 		p.tok.nextTok()
-		return &strNode{tok.val}
+		return &strNode{tok.val, pos}
+	}
+
+	if isKeyword(tok, "null") {
+		p.tok.nextTok()
+		return &nullNode{pos}
 	}
 
 	if tok.typ == identTyp {
 		p.tok.nextTok()
-		return &identNode{tok.val}
+		switch p.tok.currentTok().typ {
+		case lParenTyp:
+			return p.parseCall(tok.val, pos)
+		case arrowTyp:
+			return p.parseLambda([]string{tok.val}, pos)
+		}
+		return &identNode{tok.val, pos}
 	}
 
 	if tok.typ == lParenTyp {
 		p.tok.nextTok()
+
+		//скобки могут оказаться списком параметров лямбды: (a, b) => a + b
+		items, err := p.parseList(rParenTyp, "ожидалось ')'")
+		if err != nil {
+			return err
+		}
+
+		if p.tok.currentTok().typ == arrowTyp {
+			params := make([]string, len(items))
+			for i, item := range items {
+				ident, ok := item.(*identNode)
+				if !ok {
+					return &errNode{&SyntaxError{
+						Code: CodeUnexpectedToken,
+						Pos:  nodePos(item),
+						Msg:  "ожидалось имя параметра",
+					}}
+				}
+				params[i] = ident.val
+			}
+			return p.parseLambda(params, pos)
+		}
+
+		if len(items) != 1 {
+			return p.error(CodeUnexpectedToken, "ожидалось '=>'")
+		}
+
+		//у ((a)) остаётся внешняя скобка
+		if p.parens != nil {
+			p.parens[items[0]] = pos
+		}
+
+		return items[0]
+	}
+
+	if tok.typ == lBracketTyp {
+		p.tok.nextTok()
+
+		items, err := p.parseList(rBracketTyp, "ожидалось ',' | ']'")
+		if err != nil {
+			return err
+		}
+
+		return &listNode{items, pos}
+	}
+
+	if tok.typ == lBraceTyp {
+		return p.parseMap()
+	}
+
+	return p.error(CodeUnexpectedToken, "ожидалось число | '('")
+}
+
+// parseMap разбирает литерал словаря {"a": 1, b: 2}, текущий токен — '{'.
+// ключом может быть строка или идентификатор.
+func (p *parser) parseMap() node {
+	n := &mapNode{pos: p.tok.currentPos()}
+	p.tok.nextTok()
+
+	if p.tok.currentTok().typ == rBraceTyp {
+		p.tok.nextTok()
+		return n
+	}
+
+	seen := make(map[string]bool)
+
+	for {
+		key := p.tok.currentTok()
+		if key.typ != strTyp && key.typ != identTyp {
+			return p.error(CodeUnexpectedToken, "ожидался ключ")
+		}
+
+		if seen[key.val] {
+			return p.error(CodeUnexpectedToken, "повторяющийся ключ "+key.val)
+		}
+		seen[key.val] = true
+
+		p.tok.nextTok()
+
+		if p.tok.currentTok().typ != colonTyp {
+			return p.error(CodeUnexpectedToken, "ожидалось ':'")
+		}
+
+		p.tok.nextTok()
+
 		//parse с самым низким приоритетом
-		n := p.parse8()
-		if isErr(n) {
+		val := p.parse13()
+		if isErr(val) {
+			return val
+		}
+
+		n.keys = append(n.keys, key.val)
+		n.vals = append(n.vals, val)
+
+		switch p.tok.currentTok().typ {
+		case commaTyp:
+			p.tok.nextTok()
+		case rBraceTyp:
+			p.tok.nextTok()
 			return n
+		default:
+			return p.error(CodeUnexpectedToken, "ожидалось ',' | '}'")
+		}
+	}
+}
+
+// parseMember разбирает обращение к полю obj.name или obj?.name, текущий токен — '.' или '?.'.
+func (p *parser) parseMember(n node) node {
+	pos := p.tok.currentPos()
+	optional := p.tok.currentTok().typ == optionalDotTyp
+	p.tok.nextTok()
+
+	tok := p.tok.currentTok()
+	if tok.typ != identTyp {
+		return p.error(CodeUnexpectedToken, "ожидалось имя поля")
+	}
+
+	p.tok.nextTok()
+
+	return &memberNode{n, tok.val, memberPath(n), optional, pos}
+}
+
+// parseLambda разбирает тело лямбды, текущий токен — '=>'.
+func (p *parser) parseLambda(params []string, pos Pos) node {
+	p.tok.nextTok()
+
+	//parse с самым низким приоритетом
+	body := p.parse13()
+	if isErr(body) {
+		return body
+	}
+
+	return &lambdaNode{params, body, pos}
+}
+
+// parseCall разбирает список аргументов вызова, текущий токен — '('.
+func (p *parser) parseCall(name string, pos Pos) node {
+	p.tok.nextTok()
+
+	args, err := p.parseList(rParenTyp, "ожидалось ',' | ')'")
+	if err != nil {
+		return err
+	}
+
+	return &callNode{name, args, pos, p.dec}
+}
+
+// parseList разбирает выражения через запятую до закрывающего токена end включительно.
+func (p *parser) parseList(end uint8, msg string) ([]node, node) {
+	var items []node
+
+	if p.tok.currentTok().typ == end {
+		p.tok.nextTok()
+		return items, nil
+	}
+
+	for {
+		//parse с самым низким приоритетом
+		item := p.parse13()
+		if isErr(item) {
+			return nil, item
 		}
 
-		if p.tok.currentTok().typ != rParenTyp {
-			return &errNode{errors.New("ожидалось ')'")}
+		items = append(items, item)
+
+		switch p.tok.currentTok().typ {
+		case commaTyp:
+			p.tok.nextTok()
+		case end:
+			p.tok.nextTok()
+			return items, nil
+		default:
+			return nil, p.error(CodeUnexpectedToken, msg)
 		}
+	}
+}
+
+// parseIndex разбирает индекс xs[i] или срез xs[from:to], текущий токен — '['.
+// любая из границ среза может быть опущена.
+func (p *parser) parseIndex(n node) node {
+	pos := p.tok.currentPos()
+	p.tok.nextTok()
+
+	var from, to node
 
+	if p.tok.currentTok().typ != colonTyp {
+		from = p.parse13()
+		if isErr(from) {
+			return from
+		}
+	}
+
+	if p.tok.currentTok().typ == rBracketTyp && from != nil {
 		p.tok.nextTok()
+		return &indexNode{n, from, pos}
+	}
 
-		return n
+	if p.tok.currentTok().typ != colonTyp {
+		return p.error(CodeUnexpectedToken, "ожидалось ':' | ']'")
+	}
+
+	p.tok.nextTok()
+
+	if p.tok.currentTok().typ != rBracketTyp {
+		to = p.parse13()
+		if isErr(to) {
+			return to
+		}
 	}
 
-	return &errNode{
-		errors.New("ожидалось число | '('"),
+	if p.tok.currentTok().typ != rBracketTyp {
+		return p.error(CodeUnexpectedToken, "ожидалось ']'")
 	}
+
+	p.tok.nextTok()
+
+	return &sliceNode{n, from, to, pos}
 }
 
 func (p *parser) parse1() node {
@@ -90,32 +437,47 @@ func (p *parser) parse1() node {
 	}
 
 	if p.tok.currentTok().typ == powerTyp {
+		pos := p.tok.currentPos()
 		p.tok.nextTok()
 
-		right := p.parse1()
+		//показатель может начинаться с унарного оператора: 2 ** -1, 2 ** ~x
+		right := p.parse2()
 		if isErr(right) {
 			return right
 		}
 
-		n = &binaryNode{powOp, n, right}
+		n = &binaryNode{powOp, n, right, pos, p.dec}
 	}
 
 	return n
 }
 
+// parse2 разбирает унарные операторы, которые можно ставить подряд: - -x, !!x, -+x, ~-x.
+// унарные операторы слабее '**', поэтому -2 ** 2 == -(2 ** 2).
 func (p *parser) parse2() node {
-	if p.tok.currentTok().typ == minusTyp {
-		p.tok.nextTok()
+	var op uint8
+	switch p.tok.currentTok().typ {
+	case minusTyp:
+		op = subOp
+	case plusTyp:
+		op = addOp
+	case notTyp:
+		op = notOp
+	case tildeTyp:
+		op = bitNotOp
+	default:
+		return p.parse1()
+	}
 
-		val := p.parse1()
-		if isErr(val) {
-			return val
-		}
+	pos := p.tok.currentPos()
+	p.tok.nextTok()
 
-		return &unaryNode{subOp, val}
+	val := p.parse2()
+	if isErr(val) {
+		return val
 	}
 
-	return p.parse1()
+	return &unaryNode{op, val, pos}
 }
 
 func (p *parser) parse3() node {
@@ -125,8 +487,10 @@ func (p *parser) parse3() node {
 	}
 
 	for tok := p.tok.currentTok(); tok.typ == mulTyp ||
-		tok.typ == slashTyp; tok = p.tok.currentTok() {
-
+		tok.typ == slashTyp ||
+		tok.typ == percentTyp ||
+		tok.typ == floorDivTyp; tok = p.tok.currentTok() {
+		pos := p.tok.currentPos()
 		p.tok.nextTok()
 
 		right := p.parse2()
@@ -134,12 +498,19 @@ func (p *parser) parse3() node {
 			return right
 		}
 
-		typ := mulOp
-		if tok.typ == slashTyp {
+		var typ uint8
+		switch tok.typ {
+		case slashTyp:
 			typ = divOp
+		case percentTyp:
+			typ = modOp
+		case floorDivTyp:
+			typ = floorDivOp
+		default:
+			typ = mulOp
 		}
 
-		n = &binaryNode{typ, n, right}
+		n = &binaryNode{typ, n, right, pos, p.dec}
 	}
 
 	return n
@@ -153,7 +524,7 @@ func (p *parser) parse4() node {
 
 	for tok := p.tok.currentTok(); tok.typ == plusTyp ||
 		tok.typ == minusTyp; tok = p.tok.currentTok() {
-
+		pos := p.tok.currentPos()
 		p.tok.nextTok()
 
 		right := p.parse3()
@@ -166,7 +537,7 @@ func (p *parser) parse4() node {
 			typ = subOp
 		}
 
-		n = &binaryNode{typ, n, right}
+		n = &binaryNode{typ, n, right, pos, p.dec}
 	}
 
 	return n
@@ -178,109 +549,270 @@ func (p *parser) parse5() node {
 		return n
 	}
 
+	for tok := p.tok.currentTok(); tok.typ == shlTyp ||
+		tok.typ == shrTyp; tok = p.tok.currentTok() {
+		pos := p.tok.currentPos()
+		p.tok.nextTok()
+
+		right := p.parse4()
+		if isErr(right) {
+			return right
+		}
+
+		typ := shlOp
+		if tok.typ == shrTyp {
+			typ = shrOp
+		}
+
+		n = &binaryNode{typ, n, right, pos, p.dec}
+	}
+
+	return n
+}
+
+func (p *parser) parse6() node {
+	n := p.parse5()
+	if isErr(n) {
+		return n
+	}
+
+	for tok := p.tok.currentTok(); tok.typ == bitAndTyp; tok = p.tok.currentTok() {
+		pos := p.tok.currentPos()
+		p.tok.nextTok()
+
+		right := p.parse5()
+		if isErr(right) {
+			return right
+		}
+
+		n = &binaryNode{bitAndOp, n, right, pos, p.dec}
+	}
+
+	return n
+}
+
+func (p *parser) parse7() node {
+	n := p.parse6()
+	if isErr(n) {
+		return n
+	}
+
+	for tok := p.tok.currentTok(); tok.typ == caretTyp; tok = p.tok.currentTok() {
+		pos := p.tok.currentPos()
+		p.tok.nextTok()
+
+		right := p.parse6()
+		if isErr(right) {
+			return right
+		}
+
+		n = &binaryNode{bitXorOp, n, right, pos, p.dec}
+	}
+
+	return n
+}
+
+func (p *parser) parse8() node {
+	n := p.parse7()
+	if isErr(n) {
+		return n
+	}
+
+	for tok := p.tok.currentTok(); tok.typ == bitOrTyp; tok = p.tok.currentTok() {
+		pos := p.tok.currentPos()
+		p.tok.nextTok()
+
+		right := p.parse7()
+		if isErr(right) {
+			return right
+		}
+
+		n = &binaryNode{bitOrOp, n, right, pos, p.dec}
+	}
+
+	return n
+}
+
+func (p *parser) parse9() node {
+	n := p.parse8()
+	if isErr(n) {
+		return n
+	}
+
 	for tok := p.tok.currentTok(); tok.typ == eqTyp ||
 		tok.typ == notEqTyp ||
 		tok.typ == lessTyp ||
 		tok.typ == lessEqTyp ||
 		tok.typ == moreTyp ||
-		tok.typ == moreEqTyp; tok = p.tok.currentTok() {
-
+		tok.typ == moreEqTyp ||
+		tok.typ == matchTyp ||
+		tok.typ == notMatchTyp ||
+		isKeyword(tok, "in") ||
+		isKeyword(tok, "not"); tok = p.tok.currentTok() {
+		pos := p.tok.currentPos()
 		p.tok.nextTok()
 
-		right := p.parse4()
+		if tok.typ == matchTyp || tok.typ == notMatchTyp {
+			n = p.parseMatch(n, tok.typ == notMatchTyp, pos)
+			if isErr(n) {
+				return n
+			}
+			continue
+		}
+
+		if isKeyword(tok, "not") {
+			if !isKeyword(p.tok.currentTok(), "in") {
+				return p.error(CodeUnexpectedToken, "ожидалось in")
+			}
+			p.tok.nextTok()
+		}
+
+		right := p.parse8()
 		if isErr(right) {
 			return right
 		}
 
 		var typ uint8
-		switch tok.typ {
-		case notEqTyp:
+		switch {
+		case isKeyword(tok, "in"):
+			typ = inOp
+		case isKeyword(tok, "not"):
+			typ = notInOp
+		case tok.typ == notEqTyp:
 			typ = notEqOp
-		case moreTyp:
+		case tok.typ == moreTyp:
 			typ = moreOp
-		case lessTyp:
+		case tok.typ == lessTyp:
 			typ = lessOp
-		case moreEqTyp:
+		case tok.typ == moreEqTyp:
 			typ = moreEqOp
-		case lessEqTyp:
+		case tok.typ == lessEqTyp:
 			typ = lessEqOp
 		default:
 			typ = eqOp
 		}
 
-		n = &binaryNode{typ, n, right}
+		n = &binaryNode{typ, n, right, pos, p.dec}
 	}
 
 	return n
 }
 
-func (p *parser) parse6() node {
-	n := p.parse5()
+// parseMatch разбирает правую часть =~ и !~. шаблон-литерал компилируется сразу,
+// поэтому ошибка в нём — ошибка разбора.
+func (p *parser) parseMatch(n node, negate bool, pos Pos) node {
+	pattern := p.parse8()
+	if isErr(pattern) {
+		return pattern
+	}
+
+	match := &matchNode{val: n, pattern: pattern, negate: negate, pos: pos}
+
+	if str, ok := pattern.(*strNode); ok {
+		re, err := regexp.Compile(str.val)
+		if err != nil {
+			return &errNode{&SyntaxError{
+				Code: CodeInvalidPattern,
+				Pos:  str.pos,
+				Msg:  "некорректное регулярное выражение: " + err.Error(),
+			}}
+		}
+		match.re = re
+	}
+
+	return match
+}
+
+func (p *parser) parse10() node {
+	n := p.parse9()
 	if isErr(n) {
 		return n
 	}
 
 	for tok := p.tok.currentTok(); tok.typ == andTyp; tok = p.tok.currentTok() {
-
+		pos := p.tok.currentPos()
 		p.tok.nextTok()
 
-		right := p.parse5()
+		right := p.parse9()
 		if isErr(right) {
 			return right
 		}
 
-		n = &binaryNode{andOp, n, right}
+		n = &binaryNode{andOp, n, right, pos, p.dec}
 	}
 
 	return n
 }
 
-func (p *parser) parse7() node {
-	n := p.parse6()
+func (p *parser) parse11() node {
+	n := p.parse10()
 	if isErr(n) {
 		return n
 	}
 
 	for tok := p.tok.currentTok(); tok.typ == orTyp; tok = p.tok.currentTok() {
+		pos := p.tok.currentPos()
+		p.tok.nextTok()
+
+		right := p.parse10()
+		if isErr(right) {
+			return right
+		}
+
+		n = &binaryNode{orOp, n, right, pos, p.dec}
+	}
 
+	return n
+}
+
+// parse12 разбирает a ?? b, приоритет ниже ||, чтобы a || b ?? c означало (a || b) ?? c.
+func (p *parser) parse12() node {
+	n := p.parse11()
+	if isErr(n) {
+		return n
+	}
+
+	for tok := p.tok.currentTok(); tok.typ == nullishTyp; tok = p.tok.currentTok() {
+		pos := p.tok.currentPos()
 		p.tok.nextTok()
 
-		right := p.parse6()
+		right := p.parse11()
 		if isErr(right) {
 			return right
 		}
 
-		n = &binaryNode{orOp, n, right}
+		n = &coalesceNode{n, right, pos}
 	}
 
 	return n
 }
 
-func (p *parser) parse8() node {
-	cond := p.parse7()
+func (p *parser) parse13() node {
+	cond := p.parse12()
 	if isErr(cond) {
 		return cond
 	}
 
 	if p.tok.currentTok().typ == questionTyp {
+		pos := p.tok.currentPos()
 		p.tok.nextTok()
-		ifTrue := p.parse8()
+		ifTrue := p.parse13()
 		if isErr(ifTrue) {
 			return ifTrue
 		}
 
 		if p.tok.currentTok().typ != colonTyp {
-			return &errNode{errors.New("expected colon token")}
+			return p.error(CodeUnexpectedToken, "ожидалось ':'")
 		}
 
 		p.tok.nextTok()
 
-		ifFalse := p.parse8()
+		ifFalse := p.parse13()
 		if isErr(ifFalse) {
 			return ifFalse
 		}
 
-		return &ternaryNode{cond, ifTrue, ifFalse}
+		return &ternaryNode{cond, ifTrue, ifFalse, pos}
 	}
 
 	return cond
@@ -1,7 +1,6 @@
 package calc
 
 import (
-	"errors"
 	"reflect"
 	"testing"
 )
@@ -13,14 +12,14 @@ func Test_parse(t *testing.T) {
 	}{
 		{
 			data:     "16",
-			expected: &numNode{16.},
+			expected: &intNode{val: 16},
 		},
 		{
 			data: "16	 +32",
 			expected: &binaryNode{
 				op:    addOp,
-				left:  &numNode{16.},
-				right: &numNode{32.},
+				left:  &intNode{val: 16},
+				right: &intNode{val: 32},
 			},
 		},
 		{
@@ -29,10 +28,10 @@ func Test_parse(t *testing.T) {
 				op: addOp,
 				left: &binaryNode{
 					op:    mulOp,
-					left:  &numNode{16.},
-					right: &numNode{64.},
+					left:  &intNode{val: 16},
+					right: &intNode{val: 64},
 				},
-				right: &numNode{32.},
+				right: &intNode{val: 32},
 			},
 		},
 		{
@@ -41,10 +40,10 @@ func Test_parse(t *testing.T) {
 				op: powOp,
 				right: &binaryNode{
 					op:    powOp,
-					left:  &numNode{32.},
-					right: &numNode{64.},
+					left:  &intNode{val: 32},
+					right: &intNode{val: 64},
 				},
-				left: &numNode{16.},
+				left: &intNode{val: 16},
 			},
 		},
 		{
@@ -53,10 +52,10 @@ func Test_parse(t *testing.T) {
 				op: mulOp,
 				left: &binaryNode{
 					op:    addOp,
-					left:  &numNode{16.},
-					right: &numNode{64.},
+					left:  &intNode{val: 16},
+					right: &intNode{val: 64},
 				},
-				right: &numNode{32.},
+				right: &intNode{val: 32},
 			},
 		},
 		{
@@ -65,79 +64,167 @@ func Test_parse(t *testing.T) {
 				op: subOp,
 				left: &binaryNode{
 					op:   addOp,
-					left: &numNode{16.},
+					left: &intNode{val: 16},
 					right: &binaryNode{
 						op:   mulOp,
-						left: &numNode{64.},
+						left: &intNode{val: 64},
 						right: &binaryNode{
 							op:    powOp,
-							left:  &numNode{32.},
-							right: &numNode{64.},
+							left:  &intNode{val: 32},
+							right: &intNode{val: 64},
 						},
 					},
 				},
 				right: &binaryNode{
 					op:    divOp,
-					left:  &numNode{16.},
-					right: &numNode{64.},
+					left:  &intNode{val: 16},
+					right: &intNode{val: 64},
 				},
 			},
 		},
 		{
-			data:     "16 ++ 32",
-			expected: &errNode{errors.New("ожидалось число | '('")},
+			data: "16 + * 32",
+			expected: &errNode{&SyntaxError{
+				Code: CodeUnexpectedToken,
+				Pos:  Pos{Offset: 5, Line: 1, Column: 6},
+				Msg:  "ожидалось число | '('",
+			}},
 		},
 		{
-			data:     "32 * (16 + 64",
-			expected: &errNode{errors.New("ожидалось ')'")},
+			data: "16 ++ 32",
+			expected: &binaryNode{
+				op:    addOp,
+				left:  &intNode{val: 16},
+				right: &unaryNode{op: addOp, val: &intNode{val: 32}},
+			},
+		},
+		{
+			data: "- -!32 ** 2",
+			expected: &unaryNode{
+				op: subOp,
+				val: &unaryNode{
+					op: subOp,
+					val: &unaryNode{
+						op: notOp,
+						val: &binaryNode{
+							op:    powOp,
+							left:  &intNode{val: 32},
+							right: &intNode{val: 2},
+						},
+					},
+				},
+			},
+		},
+		{
+			data: "2 ** -1",
+			expected: &binaryNode{
+				op:    powOp,
+				left:  &intNode{val: 2},
+				right: &unaryNode{op: subOp, val: &intNode{val: 1}},
+			},
+		},
+		{
+			data: "-2 ** 2",
+			expected: &unaryNode{
+				op: subOp,
+				val: &binaryNode{
+					op:    powOp,
+					left:  &intNode{val: 2},
+					right: &intNode{val: 2},
+				},
+			},
+		},
+		{
+			data: "2 ** ~x ** 2",
+			expected: &binaryNode{
+				op:   powOp,
+				left: &intNode{val: 2},
+				right: &unaryNode{op: bitNotOp, val: &binaryNode{
+					op:    powOp,
+					left:  &identNode{val: "x"},
+					right: &intNode{val: 2},
+				}},
+			},
+		},
+		{
+			data: "32 * (16 + 64",
+			expected: &errNode{&SyntaxError{
+				Code: CodeUnexpectedToken,
+				Pos:  Pos{Offset: 13, Line: 1, Column: 14},
+				Msg:  "ожидалось ')'",
+			}},
+		},
+		{
+			data: "16 +\n\t'32",
+			expected: &errNode{&SyntaxError{
+				Code: CodeInvalidToken,
+				Pos:  Pos{Offset: 6, Line: 2, Column: 2},
+				Msg:  "ожидалось '",
+			}},
+		},
+		{
+			data: "16 > 32 ? 64",
+			expected: &errNode{&SyntaxError{
+				Code: CodeUnexpectedToken,
+				Pos:  Pos{Offset: 12, Line: 1, Column: 13},
+				Msg:  "ожидалось ':'",
+			}},
+		},
+		{
+			data: "16 32",
+			expected: &errNode{&SyntaxError{
+				Code: CodeUnexpectedToken,
+				Pos:  Pos{Offset: 3, Line: 1, Column: 4},
+				Msg:  "не удалось разобрать выражение",
+			}},
 		},
 		{data: "", expected: nil},
 		{
 			data: "16	 ==	32",
 			expected: &binaryNode{
 				op:    eqOp,
-				left:  &numNode{16.},
-				right: &numNode{32.},
+				left:  &intNode{val: 16},
+				right: &intNode{val: 32},
 			},
 		},
 		{
 			data: "	16	 !=	32",
 			expected: &binaryNode{
 				op:    notEqOp,
-				left:  &numNode{16.},
-				right: &numNode{32.},
+				left:  &intNode{val: 16},
+				right: &intNode{val: 32},
 			},
 		},
 		{
 			data: "16	 <=	32		",
 			expected: &binaryNode{
 				op:    lessEqOp,
-				left:  &numNode{16.},
-				right: &numNode{32.},
+				left:  &intNode{val: 16},
+				right: &intNode{val: 32},
 			},
 		},
 		{
 			data: "16	 >= 	32",
 			expected: &binaryNode{
 				op:    moreEqOp,
-				left:  &numNode{16.},
-				right: &numNode{32.},
+				left:  &intNode{val: 16},
+				right: &intNode{val: 32},
 			},
 		},
 		{
 			data: " 16		 >	32 ",
 			expected: &binaryNode{
 				op:    moreOp,
-				left:  &numNode{16.},
-				right: &numNode{32.},
+				left:  &intNode{val: 16},
+				right: &intNode{val: 32},
 			},
 		},
 		{
 			data: "16	 <	32	",
 			expected: &binaryNode{
 				op:    lessOp,
-				left:  &numNode{16.},
-				right: &numNode{32.},
+				left:  &intNode{val: 16},
+				right: &intNode{val: 32},
 			},
 		},
 		{
@@ -146,10 +233,10 @@ func Test_parse(t *testing.T) {
 				op: eqOp,
 				left: &binaryNode{
 					op:    addOp,
-					left:  &numNode{16.},
-					right: &numNode{16.},
+					left:  &intNode{val: 16},
+					right: &intNode{val: 16},
 				},
-				right: &numNode{32.},
+				right: &intNode{val: 32},
 			},
 		},
 		{
@@ -158,13 +245,13 @@ func Test_parse(t *testing.T) {
 				op: notEqOp,
 				left: &binaryNode{
 					op:    addOp,
-					left:  &numNode{16.},
-					right: &numNode{16.},
+					left:  &intNode{val: 16},
+					right: &intNode{val: 16},
 				},
 				right: &binaryNode{
 					op:    addOp,
-					left:  &numNode{32.},
-					right: &numNode{16.},
+					left:  &intNode{val: 32},
+					right: &intNode{val: 16},
 				},
 			},
 		},
@@ -174,13 +261,13 @@ func Test_parse(t *testing.T) {
 				op: andOp,
 				left: &binaryNode{
 					op:    lessOp,
-					left:  &numNode{16.},
-					right: &numNode{32.},
+					left:  &intNode{val: 16},
+					right: &intNode{val: 32},
 				},
 				right: &binaryNode{
 					op:    notEqOp,
-					left:  &numNode{16.},
-					right: &numNode{32.},
+					left:  &intNode{val: 16},
+					right: &intNode{val: 32},
 				},
 			},
 		},
@@ -190,38 +277,296 @@ func Test_parse(t *testing.T) {
 				op: orOp,
 				left: &binaryNode{
 					op:    lessOp,
-					left:  &numNode{16.},
-					right: &numNode{32.},
+					left:  &intNode{val: 16},
+					right: &intNode{val: 32},
 				},
 				right: &binaryNode{
 					op:    notEqOp,
-					left:  &numNode{16.},
-					right: &numNode{32.},
+					left:  &intNode{val: 16},
+					right: &intNode{val: 32},
 				},
 			},
 		},
+		{
+			data:     "now()",
+			expected: &callNode{name: "now"},
+		},
+		{
+			data: "max(16, 32 + 64)",
+			expected: &callNode{
+				name: "max",
+				args: []node{
+					&intNode{val: 16},
+					&binaryNode{
+						op:    addOp,
+						left:  &intNode{val: 32},
+						right: &intNode{val: 64},
+					},
+				},
+			},
+		},
+		{
+			data: "max(16 32)",
+			expected: &errNode{&SyntaxError{
+				Code: CodeUnexpectedToken,
+				Pos:  Pos{Offset: 7, Line: 1, Column: 8},
+				Msg:  "ожидалось ',' | ')'",
+			}},
+		},
+		{
+			data: "[16, xs][1][:-1]",
+			expected: &sliceNode{
+				val: &indexNode{
+					val: &listNode{items: []node{
+						&intNode{val: 16},
+						&identNode{val: "xs"},
+					}},
+					index: &intNode{val: 1},
+				},
+				to: &unaryNode{op: subOp, val: &intNode{val: 1}},
+			},
+		},
+		{
+			data: "-xs[0] ** 2",
+			expected: &unaryNode{
+				op: subOp,
+				val: &binaryNode{
+					op: powOp,
+					left: &indexNode{
+						val:   &identNode{val: "xs"},
+						index: &intNode{val: 0},
+					},
+					right: &intNode{val: 2},
+				},
+			},
+		},
+		{
+			data: "{'a': 1, b: user.address.city}",
+			expected: &mapNode{
+				keys: []string{"a", "b"},
+				vals: []node{
+					&intNode{val: 1},
+					&memberNode{
+						val: &memberNode{
+							val:  &identNode{val: "user"},
+							name: "address",
+							path: "user",
+						},
+						name: "city",
+						path: "user.address",
+					},
+				},
+			},
+		},
+		{
+			data: "a?.b ?? c || d ?? null",
+			expected: &coalesceNode{
+				left: &coalesceNode{
+					left: &memberNode{
+						val:      &identNode{val: "a"},
+						name:     "b",
+						path:     "a",
+						optional: true,
+					},
+					right: &binaryNode{
+						op:    orOp,
+						left:  &identNode{val: "c"},
+						right: &identNode{val: "d"},
+					},
+				},
+				right: &nullNode{},
+			},
+		},
+		{
+			data: "{'a': 1, 'a': 2}",
+			expected: &errNode{&SyntaxError{
+				Code: CodeUnexpectedToken,
+				Pos:  Pos{Offset: 9, Line: 1, Column: 10},
+				Msg:  "повторяющийся ключ a",
+			}},
+		},
+		{
+			data: "let a = 16; a; a * 2",
+			expected: &letNode{
+				name: "a",
+				val:  &intNode{val: 16},
+				body: &seqNode{
+					first: &identNode{val: "a"},
+					rest: &binaryNode{
+						op:    mulOp,
+						left:  &identNode{val: "a"},
+						right: &intNode{val: 2},
+					},
+				},
+			},
+		},
+		{
+			data: "map(xs, (x, i) => x * i)",
+			expected: &callNode{
+				name: "map",
+				args: []node{
+					&identNode{val: "xs"},
+					&lambdaNode{
+						params: []string{"x", "i"},
+						body: &binaryNode{
+							op:    mulOp,
+							left:  &identNode{val: "x"},
+							right: &identNode{val: "i"},
+						},
+					},
+				},
+			},
+		},
+		{
+			data: "() => x => -x",
+			expected: &lambdaNode{
+				params: []string{},
+				body: &lambdaNode{
+					params: []string{"x"},
+					body:   &unaryNode{op: subOp, val: &identNode{val: "x"}},
+				},
+			},
+		},
+		{
+			data: "(x, 1) => x",
+			expected: &errNode{&SyntaxError{
+				Code: CodeUnexpectedToken,
+				Pos:  Pos{Offset: 4, Line: 1, Column: 5},
+				Msg:  "ожидалось имя параметра",
+			}},
+		},
+		{
+			data: "(16, 32)",
+			expected: &errNode{&SyntaxError{
+				Code: CodeUnexpectedToken,
+				Pos:  Pos{Offset: 8, Line: 1, Column: 9},
+				Msg:  "ожидалось '=>'",
+			}},
+		},
 		{
 			data:     `'привет мир'`,
-			expected: &strNode{`привет мир`},
+			expected: &strNode{val: `привет мир`},
 		},
 		{
 			data:     `"привет мир"`,
-			expected: &strNode{`привет мир`},
+			expected: &strNode{val: `привет мир`},
 		},
 		{
 			data:     `"привет ' мир"`,
-			expected: &strNode{`привет ' мир`},
+			expected: &strNode{val: `привет ' мир`},
 		},
 		{
 			data:     `'привет " мир'`,
-			expected: &strNode{`привет " мир`},
+			expected: &strNode{val: `привет " мир`},
 		},
 	}
 
 	for _, test := range tests {
-		n := newParser(test.data).parse()
+		n := clearPos(newParser(test.data).parse())
 		if !reflect.DeepEqual(n, test.expected) {
 			t.Errorf("parse(%q): got %#v, want %#v", test.data, n, test.expected)
 		}
 	}
 }
+
+func Test_parsePos(t *testing.T) {
+	n := newParser("age >= 18 ?\n  name :\n  -`full name`").parse()
+
+	expected := &ternaryNode{
+		cond: &binaryNode{
+			op:    moreEqOp,
+			left:  &identNode{"age", Pos{Offset: 0, Line: 1, Column: 1}},
+			right: &intNode{18, Pos{Offset: 7, Line: 1, Column: 8}},
+			pos:   Pos{Offset: 4, Line: 1, Column: 5},
+		},
+		ifTrue: &identNode{"name", Pos{Offset: 14, Line: 2, Column: 3}},
+		ifFalse: &unaryNode{
+			op:  subOp,
+			val: &identNode{"full name", Pos{Offset: 24, Line: 3, Column: 4}},
+			pos: Pos{Offset: 23, Line: 3, Column: 3},
+		},
+		pos: Pos{Offset: 10, Line: 1, Column: 11},
+	}
+
+	if !reflect.DeepEqual(n, expected) {
+		t.Errorf("got %#v, want %#v", n, expected)
+	}
+}
+
+// clearPos обнуляет позиции узлов, чтобы сравнивать только структуру дерева.
+func clearPos(n node) node {
+	switch n := n.(type) {
+	case *numNode:
+		n.pos = Pos{}
+	case *strNode:
+		n.pos = Pos{}
+	case *identNode:
+		n.pos = Pos{}
+	case *unaryNode:
+		n.pos = Pos{}
+		clearPos(n.val)
+	case *binaryNode:
+		n.pos = Pos{}
+		clearPos(n.left)
+		clearPos(n.right)
+	case *ternaryNode:
+		n.pos = Pos{}
+		clearPos(n.cond)
+		clearPos(n.ifTrue)
+		clearPos(n.ifFalse)
+	case *callNode:
+		n.pos = Pos{}
+		for _, arg := range n.args {
+			clearPos(arg)
+		}
+	case *listNode:
+		n.pos = Pos{}
+		for _, item := range n.items {
+			clearPos(item)
+		}
+	case *indexNode:
+		n.pos = Pos{}
+		clearPos(n.val)
+		clearPos(n.index)
+	case *sliceNode:
+		n.pos = Pos{}
+		clearPos(n.val)
+		clearPos(n.from)
+		clearPos(n.to)
+	case *mapNode:
+		n.pos = Pos{}
+		for _, val := range n.vals {
+			clearPos(val)
+		}
+	case *memberNode:
+		n.pos = Pos{}
+		clearPos(n.val)
+	case *letNode:
+		n.pos = Pos{}
+		clearPos(n.val)
+		clearPos(n.body)
+	case *seqNode:
+		clearPos(n.first)
+		clearPos(n.rest)
+	case *lambdaNode:
+		n.pos = Pos{}
+		clearPos(n.body)
+	case *matchNode:
+		n.pos = Pos{}
+		clearPos(n.val)
+		clearPos(n.pattern)
+	case *nullNode:
+		n.pos = Pos{}
+	case *decNode:
+		n.pos = Pos{}
+	case *intNode:
+		n.pos = Pos{}
+	case *coalesceNode:
+		n.pos = Pos{}
+		clearPos(n.left)
+		clearPos(n.right)
+	case *constNode:
+		n.pos = Pos{}
+	}
+	return n
+}
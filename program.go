@@ -0,0 +1,103 @@
+package calc
+
+import "context"
+
+// Program — разобранное выражение. Разбор выполняется один раз в Compile,
+// после чего Eval можно вызывать сколько угодно раз с разными Namespace,
+// в том числе конкурентно: дерево после разбора не изменяется.
+type Program struct {
+	src  string
+	root node
+	code *chunk
+	opts options
+}
+
+// Option настраивает разбор и выполнение программы.
+type Option func(*options)
+
+type options struct {
+	decimal       *decimalContext
+	maxSteps      int
+	maxString     int
+	maxCollection int
+}
+
+// Compile разбирает выражение и возвращает ошибку разбора сразу,
+// а не во время выполнения. подвыражения из одних литералов вычисляются
+// здесь же, поэтому 1 + "a" — тоже ошибка Compile, если оно выполняется
+// всегда: в flag ? 1 : 1 + "a" ошибка вернётся из Eval, когда flag ложно.
+func Compile(src string, opts ...Option) (*Program, error) {
+	root, o, err := parseSource(src, opts, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err = fold(root)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Program{src: src, root: root, code: compile(root), opts: o}, nil
+}
+
+// parseSource применяет опции и разбирает src для Compile и Parse.
+// если parens не nil, в него записываются позиции скобок вокруг подвыражений.
+func parseSource(src string, opts []Option, parens map[node]Pos) (node, options, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	p := newParser(src)
+	p.dec = o.decimal
+	p.parens = parens
+
+	root := p.parse()
+	if root == nil {
+		return nil, o, &SyntaxError{
+			Code: CodeEmptyExpression,
+			Pos:  Pos{Line: 1, Column: 1},
+			Msg:  "пустое выражение",
+		}
+	}
+
+	if n, ok := root.(*errNode); ok {
+		return nil, o, n.err
+	}
+
+	return root, o, nil
+}
+
+// MustCompile аналогичен Compile, но паникует при ошибке разбора.
+// Удобен для инициализации глобальных переменных.
+func MustCompile(src string, opts ...Option) *Program {
+	p, err := Compile(src, opts...)
+	if err != nil {
+		panic("calc: Compile(" + src + "): " + err.Error())
+	}
+	return p
+}
+
+// Eval выполняет программу в заданном пространстве имён.
+func (p *Program) Eval(namespace Namespace) (any, error) {
+	return p.EvalContext(context.Background(), namespace)
+}
+
+// EvalContext аналогичен Eval, но прекращает выполнение и возвращает ctx.Err(),
+// когда ctx отменён.
+func (p *Program) EvalContext(ctx context.Context, namespace Namespace) (any, error) {
+	val := p.code.run(namespace, p.opts.limits(ctx))
+	if err, ok := val.(error); ok {
+		return nil, err
+	}
+
+	//числа, которые не участвовали в операциях, тоже должны стать Decimal
+	if p.opts.decimal != nil {
+		val = toDecimal(val)
+	}
+
+	return val, nil
+}
+
+// String возвращает исходный текст программы.
+func (p *Program) String() string { return p.src }
@@ -0,0 +1,80 @@
+package calc
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_Compile(t *testing.T) {
+	tests := []struct {
+		program string
+		ok      bool
+	}{
+		{"2 + 5", true},
+		{"age >= 18 ? name : 'anonymous'", true},
+		{"", false},
+		{"16 + * 32", false},
+		{"32 * (16 + 64", false},
+		{"1 ? 2", false},
+		{"16 32", false},
+	}
+
+	for _, test := range tests {
+		p, err := Compile(test.program)
+		if (err == nil) != test.ok {
+			t.Errorf("Compile(%q): unexpected error %v", test.program, err)
+		}
+
+		if test.ok && p.String() != test.program {
+			t.Errorf("Compile(%q): String() = %q", test.program, p.String())
+		}
+	}
+}
+
+func Test_Program_Eval(t *testing.T) {
+	p := MustCompile("age >= 18 ? name + ' взрослый' : name")
+
+	tests := []struct {
+		namespace namespace
+		expected  any
+	}{
+		{namespace{"name": "tyson", "age": 32}, "tyson взрослый"},
+		{namespace{"name": "paul", "age": 12}, "paul"},
+		{namespace{"name": "mike", "age": int64(18)}, "mike взрослый"},
+	}
+
+	for _, test := range tests {
+		val, err := p.Eval(test.namespace)
+		if err != nil {
+			t.Errorf("%v: unexpected error %v", test.namespace, err)
+		}
+
+		if !reflect.DeepEqual(val, test.expected) {
+			t.Errorf("%v: got %v, want %v", test.namespace, val, test.expected)
+		}
+	}
+
+	if _, err := p.Eval(namespace{"age": 32}); err == nil {
+		t.Errorf("expected error for missing identifier")
+	}
+
+	//без Namespace идентификаторы неизвестны, а функции и литералы работают
+	var target *UnknownIdentifierError
+	if _, err := p.Eval(nil); !errors.As(err, &target) || target.Name != "age" {
+		t.Errorf("Eval(nil): got %v", err)
+	}
+	if val := Calc("len([1, 2])", nil); val != int64(2) {
+		t.Errorf("Calc(len, nil): got %#v", val)
+	}
+}
+
+func Test_MustCompile(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("MustCompile: expected panic")
+		}
+	}()
+
+	MustCompile("32 * (16 + 64")
+}
@@ -0,0 +1,112 @@
+package calc
+
+import (
+	"regexp"
+	"sync"
+)
+
+/*
+x =~ pattern и x !~ pattern проверяют, найдено ли совпадение с регулярным выражением
+RE2 (пакет regexp) в строке x. шаблон-литерал компилируется при разборе,
+а шаблоны из Namespace и других выражений — при первом использовании,
+после чего хранятся в patternCache.
+*/
+
+type matchNode struct {
+	val     node
+	pattern node
+	re      *regexp.Regexp //скомпилирован при разборе, если pattern — литерал
+	negate  bool           //!~
+	pos     Pos
+}
+
+func (n *matchNode) exec(namespace Namespace) any {
+	val := n.val.exec(namespace)
+	if _, ok := val.(error); ok {
+		return val
+	}
+
+	var pattern any
+	if n.re == nil {
+		pattern = n.pattern.exec(namespace)
+		if _, ok := pattern.(error); ok {
+			return pattern
+		}
+	}
+
+	return n.apply(val, pattern)
+}
+
+// apply проверяет совпадение; pattern не используется, если шаблон скомпилирован при разборе.
+func (n *matchNode) apply(val, pattern any) any {
+	op := "=~"
+	if n.negate {
+		op = "!~"
+	}
+
+	re := n.re
+	if re == nil {
+		str, ok := pattern.(string)
+		if !ok {
+			return newTypeError(CodeInvalidOperand, n.pos, op, val, pattern)
+		}
+
+		var err error
+		re, err = compilePattern(str)
+		if err != nil {
+			return &PatternError{Code: CodeInvalidPattern, Pos: nodePos(n.pattern), Pattern: str, Err: err}
+		}
+	}
+
+	str, ok := val.(string)
+	if !ok {
+		return newTypeError(CodeInvalidOperand, n.pos, op, val)
+	}
+
+	return re.MatchString(str) != n.negate
+}
+
+// maxCachedPatterns ограничивает patternCache: шаблоны могут приходить от пользователей.
+const maxCachedPatterns = 256
+
+var patternCache = struct {
+	sync.RWMutex
+	m map[string]*regexp.Regexp
+}{m: make(map[string]*regexp.Regexp)}
+
+// compilePattern компилирует шаблон или берёт его из кэша.
+// при переполнении кэш очищается целиком.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	patternCache.RLock()
+	re, ok := patternCache.m[pattern]
+	patternCache.RUnlock()
+	if ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	patternCache.Lock()
+	if len(patternCache.m) >= maxCachedPatterns {
+		clear(patternCache.m)
+	}
+	patternCache.m[pattern] = re
+	patternCache.Unlock()
+
+	return re, nil
+}
+
+func init() {
+	register("matches", matches)
+}
+
+func matches(s, pattern string) (bool, error) {
+	re, err := compilePattern(pattern)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(s), nil
+}
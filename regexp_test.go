@@ -0,0 +1,126 @@
+package calc
+
+import (
+	"errors"
+	"reflect"
+	"regexp/syntax"
+	"testing"
+)
+
+func Test_match(t *testing.T) {
+	ns := namespace{
+		"email":   "tyson@example.com",
+		"phone":   "+7 (999) 123-45-67",
+		"pattern": `^\+7`,
+		"digits":  `\d+`,
+	}
+
+	tests := []struct {
+		program  string
+		expected any
+	}{
+		{`email =~ "@example\.com$"`, true},
+		{`email !~ "@example\.com$"`, false},
+		{`email =~ '^[a-z]+@'`, true},
+		{`email =~ '^\d'`, false},
+		{"phone =~ pattern", true},
+		{"email =~ pattern", false},
+		{"'abc' =~ '(?i)ABC'", true},
+		{"'привет' =~ '^п.{5}$'", true},
+		{"email =~ '' + 'tyson'", true},
+		{"(email =~ 'tyson') == (phone =~ '999')", true},
+		{"email =~ 'tyson' && phone !~ '[a-z]'", true},
+		{"matches(phone, digits)", true},
+		{"matches('abc', '^b')", false},
+		{"filter(['a1', 'b', 'c2'], (x) => x =~ digits)", []any{"a1", "c2"}},
+	}
+
+	for _, test := range tests {
+		val := Calc(test.program, ns)
+		if !reflect.DeepEqual(val, test.expected) {
+			t.Errorf("%s: got %#v, want %#v", test.program, val, test.expected)
+		}
+	}
+}
+
+func Test_match_errors(t *testing.T) {
+	ns := namespace{"bad": "(", "n": 1}
+
+	tests := []struct {
+		program  string
+		expected error
+	}{
+		{
+			program: "'a' =~ '('",
+			expected: &SyntaxError{
+				Code: CodeInvalidPattern,
+				Pos:  Pos{Offset: 7, Line: 1, Column: 8},
+				Msg:  "некорректное регулярное выражение: error parsing regexp: missing closing ): `(`",
+			},
+		},
+		{
+			program: "'a' =~ bad",
+			expected: &PatternError{
+				Code:    CodeInvalidPattern,
+				Pos:     Pos{Offset: 7, Line: 1, Column: 8},
+				Pattern: "(",
+				Err:     &syntax.Error{Code: syntax.ErrMissingParen, Expr: "("},
+			},
+		},
+		{
+			program: "n =~ 'a'",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 2, Line: 1, Column: 3},
+				Op:    "=~",
+				Types: []string{"number"},
+			},
+		},
+		{
+			program: "'a' !~ n",
+			expected: &TypeError{
+				Code:  CodeInvalidOperand,
+				Pos:   Pos{Offset: 4, Line: 1, Column: 5},
+				Op:    "!~",
+				Types: []string{"string", "number"},
+			},
+		},
+		{
+			program: "'a' =~",
+			expected: &SyntaxError{
+				Code: CodeUnexpectedToken,
+				Pos:  Pos{Offset: 6, Line: 1, Column: 7},
+				Msg:  "ожидалось число | '('",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, ok := Calc(test.program, ns).(error)
+		if !ok {
+			t.Errorf("%q: expected error", test.program)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%q: got %#v, want %#v", test.program, got, test.expected)
+		}
+	}
+
+	var callErr *CallError
+	if err, _ := Calc("matches('a', bad)", ns).(error); !errors.As(err, &callErr) || callErr.Code != CodeCallFailed {
+		t.Errorf("matches with invalid pattern: got %v", err)
+	}
+}
+
+func Test_compilePattern(t *testing.T) {
+	first, err := compilePattern(`^\w+$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, _ := compilePattern(`^\w+$`)
+	if first != second {
+		t.Errorf("pattern must be cached")
+	}
+}
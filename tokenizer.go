@@ -8,11 +8,14 @@ import (
 type tokenizer struct {
 	data   []rune
 	cursor int
+	line   int
+	column int
 	tok    token //последний прочитанный токен
+	pos    Pos   //позиция начала последнего прочитанного токена
 }
 
 func newTokenizer(data string) *tokenizer {
-	return &tokenizer{data: []rune(data)}
+	return &tokenizer{data: []rune(data), line: 1, column: 1}
 }
 
 func (t *tokenizer) char() rune {
@@ -29,7 +32,18 @@ func (t *tokenizer) nextChar() rune {
 	return t.data[t.cursor+1]
 }
 
-func (t *tokenizer) next() { t.cursor++ }
+func (t *tokenizer) next() {
+	if t.char() == '\n' {
+		t.line++
+		t.column = 0
+	}
+	t.cursor++
+	t.column++
+}
+
+func (t *tokenizer) position() Pos {
+	return Pos{Offset: t.cursor, Line: t.line, Column: t.column}
+}
 
 func (t *tokenizer) skipSpace() {
 	for {
@@ -64,6 +78,28 @@ const (
 	colonTyp
 	strTyp
 	identTyp
+	commaTyp
+	notTyp
+	percentTyp
+	floorDivTyp
+	bitAndTyp
+	bitOrTyp
+	caretTyp
+	tildeTyp
+	shlTyp
+	shrTyp
+	lBracketTyp
+	rBracketTyp
+	lBraceTyp
+	rBraceTyp
+	dotTyp
+	assignTyp
+	semicolonTyp
+	arrowTyp
+	matchTyp
+	notMatchTyp
+	nullishTyp
+	optionalDotTyp
 )
 
 type token struct {
@@ -85,6 +121,8 @@ func (t *tokenizer) nextTok() (tok token) {
 
 	t.skipSpace()
 
+	t.pos = t.position()
+
 	if t.char() == 0 {
 		return token{typ: eofTyp}
 	}
@@ -180,7 +218,7 @@ func (t *tokenizer) readIdent() token {
 			}
 
 			if t.char() == 0 {
-				return token{errTyp, ""}
+				return token{errTyp, "ожидалось `"}
 			}
 
 			builder.WriteRune(t.char())
@@ -210,59 +248,96 @@ func (t *tokenizer) readOperator() token {
 	switch t.char() {
 	case '?':
 		t.next()
+		switch {
+		case t.char() == '?':
+			t.next()
+			return token{typ: nullishTyp}
+		case t.char() == '.' && !unicode.IsDigit(t.nextChar()):
+			//a ?.5 : 1 — это тернарный оператор с числом .5
+			t.next()
+			return token{typ: optionalDotTyp}
+		}
 		return token{typ: questionTyp}
 	case ':':
 		t.next()
 		return token{typ: colonTyp}
 
 	case '=':
-		if t.nextChar() != '=' {
-			return token{typ: emptyTyp}
-		}
 		t.next()
+		switch t.char() {
+		case '=':
+			t.next()
+			return token{typ: eqTyp}
+		case '>':
+			t.next()
+			return token{typ: arrowTyp}
+		case '~':
+			t.next()
+			return token{typ: matchTyp}
+		}
+		return token{typ: assignTyp}
+
+	case ';':
 		t.next()
-		return token{typ: eqTyp}
+		return token{typ: semicolonTyp}
 
 	case '!':
-		if t.nextChar() != '=' {
-			return token{typ: emptyTyp}
-		}
 		t.next()
-		t.next()
-		return token{typ: notEqTyp}
+		switch t.char() {
+		case '=':
+			t.next()
+			return token{typ: notEqTyp}
+		case '~':
+			t.next()
+			return token{typ: notMatchTyp}
+		}
+		return token{typ: notTyp}
 
 	case '<':
 		t.next()
-		if t.char() != '=' {
-			return token{typ: lessTyp}
+		switch t.char() {
+		case '=':
+			t.next()
+			return token{typ: lessEqTyp}
+		case '<':
+			t.next()
+			return token{typ: shlTyp}
 		}
-		t.next()
-		return token{typ: lessEqTyp}
+		return token{typ: lessTyp}
 
 	case '>':
 		t.next()
-		if t.char() != '=' {
-			return token{typ: moreTyp}
+		switch t.char() {
+		case '=':
+			t.next()
+			return token{typ: moreEqTyp}
+		case '>':
+			t.next()
+			return token{typ: shrTyp}
 		}
-		t.next()
-		return token{typ: moreEqTyp}
+		return token{typ: moreTyp}
 
 	case '&':
-		if t.nextChar() != '&' {
-			return token{typ: emptyTyp}
-		}
 		t.next()
+		if t.char() != '&' {
+			return token{typ: bitAndTyp}
+		}
 		t.next()
 		return token{typ: andTyp}
 
 	case '|':
-		if t.nextChar() != '|' {
-			return token{typ: emptyTyp}
-		}
 		t.next()
+		if t.char() != '|' {
+			return token{typ: bitOrTyp}
+		}
 		t.next()
 		return token{typ: orTyp}
 
+	case '^':
+		tok.typ = caretTyp
+	case '~':
+		tok.typ = tildeTyp
+
 	case '+':
 		tok.typ = plusTyp
 	case '-':
@@ -275,11 +350,30 @@ func (t *tokenizer) readOperator() token {
 		}
 		tok.typ = mulTyp
 	case '/':
+		if t.nextChar() == '/' {
+			t.next()
+			tok.typ = floorDivTyp
+			break
+		}
 		tok.typ = slashTyp
+	case '%':
+		tok.typ = percentTyp
 	case '(':
 		tok.typ = lParenTyp
 	case ')':
 		tok.typ = rParenTyp
+	case ',':
+		tok.typ = commaTyp
+	case '[':
+		tok.typ = lBracketTyp
+	case ']':
+		tok.typ = rBracketTyp
+	case '{':
+		tok.typ = lBraceTyp
+	case '}':
+		tok.typ = rBraceTyp
+	case '.':
+		tok.typ = dotTyp
 	default:
 		return token{typ: emptyTyp}
 	}
@@ -290,3 +384,5 @@ func (t *tokenizer) readOperator() token {
 }
 
 func (t *tokenizer) currentTok() token { return t.tok }
+
+func (t *tokenizer) currentPos() Pos { return t.pos }
@@ -77,7 +77,10 @@ func Test_readOperator(t *testing.T) {
 		tr("--", token{typ: minusTyp}, 1),
 		tr("**", token{typ: powerTyp}, 2),
 		tr("****", token{typ: powerTyp}, 2),
-		tr("//", token{typ: slashTyp}, 1),
+		tr("//", token{typ: floorDivTyp}, 2),
+		tr("///", token{typ: floorDivTyp}, 2),
+		tr("%", token{typ: percentTyp}, 1),
+		tr("%%", token{typ: percentTyp}, 1),
 		tr("+-", token{typ: plusTyp}, 1),
 		tr("-+", token{typ: minusTyp}, 1),
 		tr("*+", token{typ: mulTyp}, 1),
@@ -102,21 +105,47 @@ func Test_readOperator(t *testing.T) {
 		tr("<=", token{typ: lessEqTyp}, 2),
 		tr("===", token{typ: eqTyp}, 2),
 		tr("!==", token{typ: notEqTyp}, 2),
-		tr(">>", token{typ: moreTyp}, 1),
+		tr("!", token{typ: notTyp}, 1),
+		tr("=~", token{typ: matchTyp}, 2),
+		tr("!~", token{typ: notMatchTyp}, 2),
+		tr("!!", token{typ: notTyp}, 1),
+		tr("! =", token{typ: notTyp}, 1),
+		tr(">>", token{typ: shrTyp}, 2),
 		tr(">=>=", token{typ: moreEqTyp}, 2),
-		tr("<<<", token{typ: lessTyp}, 1),
-		tr("<<==", token{typ: lessTyp}, 1),
-		tr("=", token{typ: emptyTyp}, 0),
+		tr("<<<", token{typ: shlTyp}, 2),
+		tr("<<==", token{typ: shlTyp}, 2),
+		tr("<=<", token{typ: lessEqTyp}, 2),
+		tr("> >", token{typ: moreTyp}, 1),
+		tr("=", token{typ: assignTyp}, 1),
+		tr("= =", token{typ: assignTyp}, 1),
+		tr("=>", token{typ: arrowTyp}, 2),
+		tr("==>", token{typ: eqTyp}, 2),
+		tr(";", token{typ: semicolonTyp}, 1),
 		tr("&&", token{typ: andTyp}, 2),
 		tr("||", token{typ: orTyp}, 2),
 		tr("&&||", token{typ: andTyp}, 2),
 		tr("||&&", token{typ: orTyp}, 2),
 		tr("&&&&", token{typ: andTyp}, 2),
 		tr("||||", token{typ: orTyp}, 2),
+		tr("&", token{typ: bitAndTyp}, 1),
+		tr("& &", token{typ: bitAndTyp}, 1),
+		tr("|", token{typ: bitOrTyp}, 1),
+		tr("|&", token{typ: bitOrTyp}, 1),
+		tr("^", token{typ: caretTyp}, 1),
+		tr("~", token{typ: tildeTyp}, 1),
+		tr("~~", token{typ: tildeTyp}, 1),
 		tr("?", token{typ: questionTyp}, 1),
 		tr(":", token{typ: colonTyp}, 1),
-		tr("??", token{typ: questionTyp}, 1),
+		tr("??", token{typ: nullishTyp}, 2),
+		tr("???", token{typ: nullishTyp}, 2),
+		tr("?.", token{typ: optionalDotTyp}, 2),
+		tr("?.5", token{typ: questionTyp}, 1),
 		tr("::", token{typ: colonTyp}, 1),
+		tr(",", token{typ: commaTyp}, 1),
+		tr(",,", token{typ: commaTyp}, 1),
+		tr("[", token{typ: lBracketTyp}, 1),
+		tr("]", token{typ: rBracketTyp}, 1),
+		tr("[]", token{typ: lBracketTyp}, 1),
 	}
 
 	for _, test := range tests {
@@ -242,9 +271,29 @@ func Test_read(t *testing.T) {
 			tok: newTokenizer("32.	0"),
 			expected: []item{
 				{token{numTyp, "32"}, 2},
-				{token{errTyp, "неизвестный символ ."}, 2},
-				{token{errTyp, "неизвестный символ ."}, 2},
-				{token{errTyp, "неизвестный символ ."}, 2},
+				{token{typ: dotTyp}, 3},
+				{token{numTyp, "0"}, 5},
+				{token{typ: eofTyp}, 5},
+			},
+		},
+		{
+			tok: newTokenizer("32 # 0"),
+			expected: []item{
+				{token{numTyp, "32"}, 2},
+				{token{errTyp, "неизвестный символ #"}, 3},
+				{token{errTyp, "неизвестный символ #"}, 3},
+			},
+		},
+		{
+			tok: newTokenizer("user.`full name`.x{}"),
+			expected: []item{
+				{token{identTyp, "user"}, 4},
+				{token{typ: dotTyp}, 5},
+				{token{identTyp, "full name"}, 16},
+				{token{typ: dotTyp}, 17},
+				{token{identTyp, "x"}, 18},
+				{token{typ: lBraceTyp}, 19},
+				{token{typ: rBraceTyp}, 20},
 			},
 		},
 		{
@@ -0,0 +1,269 @@
+package calc
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Kind — вид статического типа.
+type Kind uint8
+
+const (
+	KindAny    Kind = iota // тип неизвестен, проверки пропускаются
+	KindNull               // null
+	KindBool               // bool
+	KindInt                // int64
+	KindFloat              // float64
+	KindNumber             // любое число: int64, float64 или Decimal
+	KindString             // string
+	KindList               // список
+	KindObject             // словарь или структура
+	KindFunc               // функция или лямбда
+)
+
+// Type — статический тип значения, который выводит Check.
+type Type struct {
+	kind   Kind
+	elem   *Type            //тип элементов списка
+	fields map[string]*Type //поля объекта, nil — поля неизвестны
+	key    *Type            //тип ключей словаря Go, nil — строки
+	params []*Type          //параметры функции, nil — любые аргументы
+	result *Type            //результат функции
+}
+
+/*
+базовые типы создаются функциями, а не хранятся в переменных пакета:
+каждый вызов возвращает новый *Type, поэтому изменение типа, полученного
+из Check или схемы, не затрагивает другие проверки.
+*/
+
+// TypeAny возвращает неизвестный тип, с которым проходят любые проверки.
+func TypeAny() *Type { return &Type{kind: KindAny} }
+
+// TypeNull возвращает тип null.
+func TypeNull() *Type { return &Type{kind: KindNull} }
+
+// TypeBool возвращает тип bool.
+func TypeBool() *Type { return &Type{kind: KindBool} }
+
+// TypeInt возвращает тип целого числа int64.
+func TypeInt() *Type { return &Type{kind: KindInt} }
+
+// TypeFloat возвращает тип числа float64.
+func TypeFloat() *Type { return &Type{kind: KindFloat} }
+
+// TypeNumber возвращает тип любого числа: int64, float64 или Decimal.
+func TypeNumber() *Type { return &Type{kind: KindNumber} }
+
+// TypeString возвращает тип строки.
+func TypeString() *Type { return &Type{kind: KindString} }
+
+// ListOf возвращает тип списка с элементами типа elem.
+func ListOf(elem *Type) *Type {
+	return &Type{kind: KindList, elem: elem}
+}
+
+// ObjectOf возвращает тип объекта с заданными полями.
+// при fields == nil обращение к любому полю даёт Any.
+func ObjectOf(fields map[string]*Type) *Type {
+	return &Type{kind: KindObject, fields: fields}
+}
+
+// FuncOf возвращает тип функции с результатом result и параметрами params.
+func FuncOf(result *Type, params ...*Type) *Type {
+	if params == nil {
+		params = []*Type{}
+	}
+	return &Type{kind: KindFunc, params: params, result: result}
+}
+
+// TypeOf возвращает тип значения Go по тем же правилам, что и normalize,
+// поля структур — как в NamespaceOf.
+func TypeOf(v any) *Type {
+	if v == nil {
+		return TypeNull()
+	}
+	return typeOfGo(reflect.TypeOf(v), make(map[reflect.Type]bool))
+}
+
+func typeOfGo(t reflect.Type, seen map[reflect.Type]bool) *Type {
+	switch t {
+	case decimalType, numberType:
+		return TypeNumber()
+	case reflect.TypeOf((*lambda)(nil)):
+		return &Type{kind: KindFunc, result: TypeAny()}
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return TypeInt()
+	case reflect.Uint, reflect.Uint64:
+		//значения больше math.MaxInt64 становятся Decimal
+		return TypeNumber()
+	case reflect.Float32, reflect.Float64:
+		return TypeFloat()
+	case reflect.String:
+		return TypeString()
+	case reflect.Bool:
+		return TypeBool()
+	case reflect.Slice, reflect.Array:
+		return ListOf(typeOfGo(t.Elem(), seen))
+	case reflect.Map:
+		//codes map[int]string: 404 in codes и codes[404] проверяются по типу ключа
+		return &Type{kind: KindObject, key: typeOfGo(t.Key(), seen)}
+	case reflect.Func:
+		return &Type{kind: KindFunc, result: TypeAny()}
+	case reflect.Pointer:
+		return typeOfGo(t.Elem(), seen)
+	case reflect.Struct:
+		//в рекурсивных структурах вроде user.Manager *user вложенный тип — объект с любыми полями
+		if seen[t] {
+			return ObjectOf(nil)
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		fields := make(map[string]*Type)
+		for name, index := range structFields(t) {
+			fields[name] = typeOfGo(t.FieldByIndex(index).Type, seen)
+		}
+		return ObjectOf(fields)
+	default:
+		return TypeAny()
+	}
+}
+
+// Kind возвращает вид типа.
+func (t *Type) Kind() Kind { return t.kind }
+
+// Elem возвращает тип элементов списка или nil для других типов.
+func (t *Type) Elem() *Type { return t.elem }
+
+// Field возвращает тип поля объекта.
+func (t *Type) Field(name string) (*Type, bool) {
+	if t.kind != KindObject {
+		return nil, false
+	}
+	if t.fields == nil {
+		return TypeAny(), true
+	}
+	field, ok := t.fields[name]
+	return field, ok
+}
+
+// keyType возвращает тип ключей объекта для in и индекса.
+func (t *Type) keyType() *Type {
+	if t.key == nil {
+		return TypeString()
+	}
+	return t.key
+}
+
+// Result возвращает тип результата функции или nil для других типов.
+func (t *Type) Result() *Type { return t.result }
+
+// String возвращает запись типа: int, list[string], {name: string}.
+func (t *Type) String() string {
+	switch t.kind {
+	case KindNull:
+		return "null"
+	case KindBool:
+		return "bool"
+	case KindInt:
+		return "int"
+	case KindFloat:
+		return "float"
+	case KindNumber:
+		return "number"
+	case KindString:
+		return "string"
+	case KindList:
+		if t.elem.kind == KindAny {
+			return "list"
+		}
+		return "list[" + t.elem.String() + "]"
+	case KindObject:
+		if t.key != nil && t.key.kind != KindString {
+			return "map[" + t.key.String() + "]"
+		}
+		if t.fields == nil {
+			return "map"
+		}
+		names := make([]string, 0, len(t.fields))
+		for name := range t.fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for i, name := range names {
+			names[i] = name + ": " + t.fields[name].String()
+		}
+		return "{" + strings.Join(names, ", ") + "}"
+	case KindFunc:
+		return "function"
+	default:
+		return "any"
+	}
+}
+
+func (t *Type) numeric() bool {
+	return t.kind == KindInt || t.kind == KindFloat || t.kind == KindNumber
+}
+
+// arithmetic возвращает тип результата +, -, * и других операций над числами.
+func arithmetic(l, r *Type) *Type {
+	switch {
+	case l.kind == KindInt && r.kind == KindInt:
+		return TypeInt()
+	case l.kind == KindNumber || r.kind == KindNumber:
+		return TypeNumber()
+	default:
+		return TypeFloat()
+	}
+}
+
+// unify возвращает общий тип для ветвей ?: и элементов списка.
+func unify(l, r *Type) *Type {
+	switch {
+	case l.kind == KindNull:
+		return r
+	case r.kind == KindNull:
+		return l
+	case l.numeric() && r.numeric():
+		if l.kind == r.kind {
+			return l
+		}
+		return TypeNumber()
+	case l.kind != r.kind:
+		return TypeAny()
+	case l.kind == KindList:
+		return ListOf(unify(l.elem, r.elem))
+	case l.kind == KindObject && l.String() != r.String():
+		//ключи объектов разных типов известны только при выполнении
+		return &Type{kind: KindObject, key: unify(l.keyType(), r.keyType())}
+	case l.kind == KindFunc && l.String() != r.String():
+		return &Type{kind: KindFunc, result: TypeAny()}
+	default:
+		return l
+	}
+}
+
+// assignable сообщает, можно ли передать значение типа arg в параметр типа param.
+// числа взаимозаменяемы: целые параметры принимают float64 без дробной части.
+func assignable(param, arg *Type) bool {
+	switch {
+	case param.kind == KindAny || arg.kind == KindAny:
+		return true
+	case arg.kind == KindNull:
+		return param.kind == KindList || param.kind == KindObject || param.kind == KindFunc
+	case param.numeric():
+		return arg.numeric()
+	case param.kind != arg.kind:
+		return false
+	case param.kind == KindList:
+		return assignable(param.elem, arg.elem)
+	default:
+		return true
+	}
+}
@@ -0,0 +1,198 @@
+package calc
+
+import "math"
+
+/*
+value — ячейка стека VM. int64, float64 и bool лежат в bits без упаковки
+в interface, поэтому типизированные инструкции insArith, insCompare и переходы
+по bool не выделяют память и не разбирают тип через type switch на каждом шаге.
+остальные значения (строки, списки, Decimal, функции) лежат в ref.
+нулевое value — null.
+*/
+
+const (
+	valNull  uint8 = iota //null
+	valBool               //bool в bits: 0 или 1
+	valInt                //int64 в bits
+	valFloat              //float64 в bits, math.Float64bits
+	valRef                //любое другое значение в ref
+)
+
+type value struct {
+	kind uint8
+	bits uint64
+	ref  any
+}
+
+func boolValue(b bool) value {
+	if b {
+		return value{kind: valBool, bits: 1}
+	}
+	return value{kind: valBool}
+}
+
+func intValue(i int64) value { return value{kind: valInt, bits: uint64(i)} }
+
+func floatValue(f float64) value { return value{kind: valFloat, bits: math.Float64bits(f)} }
+
+func (v value) bool() bool     { return v.bits != 0 }
+func (v value) int() int64     { return int64(v.bits) }
+func (v value) float() float64 { return math.Float64frombits(v.bits) }
+func (v value) numeric() bool  { return v.kind == valInt || v.kind == valFloat }
+func (v value) str() (string, bool) {
+	s, ok := v.ref.(string)
+	return s, ok && v.kind == valRef
+}
+
+// num возвращает число как float64: целое приводится, как в promote.
+func (v value) num() float64 {
+	if v.kind == valInt {
+		return float64(v.int())
+	}
+	return v.float()
+}
+
+// box возвращает значение выражения, хранящееся в ячейке.
+func (v value) box() any {
+	switch v.kind {
+	case valBool:
+		return v.bool()
+	case valInt:
+		return v.int()
+	case valFloat:
+		return v.float()
+	case valRef:
+		return v.ref
+	default:
+		return nil
+	}
+}
+
+// toValue раскладывает результат узла по ячейке, ошибка возвращается отдельно.
+func toValue(val any) (value, error) {
+	switch v := val.(type) {
+	case nil:
+		return value{}, nil
+	case bool:
+		return boolValue(v), nil
+	case int64:
+		return intValue(v), nil
+	case float64:
+		return floatValue(v), nil
+	case error:
+		return value{}, v
+	default:
+		//val, а не v: повторная упаковка строки в interface выделила бы память
+		return value{kind: valRef, ref: val}, nil
+	}
+}
+
+/*
+arith выполняет +, -, *, /, // и % над целыми и дробными числами так же,
+как binaryNode.apply. ok == false — операнды другого типа или результат —
+ошибка (переполнение, деление на ноль): тогда инструкция вызывает apply,
+и ошибка получается той же, что и при выполнении дерева.
+*/
+func arith(op uint8, l, r value) (value, bool) {
+	if l.kind == valInt && r.kind == valInt {
+		a, b := l.int(), r.int()
+		switch op {
+		case addOp:
+			if res := a + b; (res > a) == (b > 0) {
+				return intValue(res), true
+			}
+		case subOp:
+			if res := a - b; (res < a) == (b > 0) {
+				return intValue(res), true
+			}
+		case mulOp:
+			if res, ok := mulInt(a, b); ok {
+				return intValue(res), true
+			}
+		case divOp:
+			if b != 0 {
+				return floatValue(float64(a) / float64(b)), true
+			}
+		case modOp, floorDivOp:
+			//деление на ноль и math.MinInt64 // -1 обрабатывает apply
+			if b == 0 || b == -1 {
+				return value{}, false
+			}
+			q, m := floorDivInt(a, b)
+			if op == modOp {
+				return intValue(m), true
+			}
+			return intValue(q), true
+		}
+		return value{}, false
+	}
+
+	if !l.numeric() || !r.numeric() {
+		return value{}, false
+	}
+
+	a, b := l.num(), r.num()
+	switch op {
+	case addOp:
+		return floatValue(a + b), true
+	case subOp:
+		return floatValue(a - b), true
+	case mulOp:
+		return floatValue(a * b), true
+	case divOp:
+		if b != 0 {
+			return floatValue(a / b), true
+		}
+	case modOp:
+		if b != 0 {
+			return floatValue(floorMod(a, b)), true
+		}
+	case floorDivOp:
+		if b != 0 {
+			return floatValue(math.Floor(a / b)), true
+		}
+	}
+	return value{}, false
+}
+
+// compare выполняет ==, !=, <, <=, >, >= над числами, строками, bool и null.
+// ok == false — операнды, для которых нужен apply.
+func compare(op uint8, l, r value) (value, bool) {
+	equality := op == eqOp || op == notEqOp
+
+	switch {
+	case l.kind == valInt && r.kind == valInt:
+		return boolValue(ordered(op, l.int(), r.int())), true
+	case l.numeric() && r.numeric():
+		return boolValue(ordered(op, l.num(), r.num())), true
+	//null равен только null
+	case equality && (l.kind == valNull || r.kind == valNull):
+		return boolValue((l.kind == r.kind) == (op == eqOp)), true
+	case equality && l.kind == valBool && r.kind == valBool:
+		return boolValue((l.bits == r.bits) == (op == eqOp)), true
+	}
+
+	ls, lok := l.str()
+	rs, rok := r.str()
+	if lok && rok {
+		return boolValue(ordered(op, ls, rs)), true
+	}
+	return value{}, false
+}
+
+func ordered[T int64 | float64 | string](op uint8, a, b T) bool {
+	switch op {
+	case eqOp:
+		return a == b
+	case notEqOp:
+		return a != b
+	case lessOp:
+		return a < b
+	case lessEqOp:
+		return a <= b
+	case moreOp:
+		return a > b
+	default:
+		return a >= b
+	}
+}
@@ -0,0 +1,545 @@
+package calc
+
+import "math"
+
+/*
+Eval выполняет не дерево, а байткод: compile один раз превращает дерево
+в плоский список инструкций, а run исполняет его на стеке значений без рекурсии
+по узлам. семантика операторов не дублируется: инструкции вызывают те же apply,
+что и exec, поэтому порядок вычисления и ошибки совпадают с деревом.
+exec остаётся для свёртки констант в Compile.
+
+стек хранит value: целые, дробные и bool не упаковываются в interface.
+insArith и insCompare считают над ними сами, а для остальных типов,
+переполнения и деления на ноль вызывают apply. константа справа
+от оператора (age * 2, name != 'paul') не кладётся на стек, а берётся
+из consts. ошибка инструкции не кладётся на стек, а передаётся отдельно.
+
+ошибка инструкции прерывает выполнение, если нет активного try от ??:
+тогда стек и Namespace откатываются к состоянию начала левой части,
+а catch решает, подменить ли ошибку на null, как coalesceNode.exec.
+*/
+
+const (
+	insConst        uint8 = iota + 1 //значение consts[arg]
+	insFail                          //ошибка consts[arg] из errNode
+	insLoad                          //identNode
+	insLoadSequence                  //identNode.sequence под индексом или срезом
+	insUnary                         //unaryNode.apply над вершиной стека
+	insArith                         //+, -, *, /, //, % без DecimalMode: целые и дробные без apply
+	insArithConst                    //insArith с правым операндом consts[arg]
+	insCompare                       //сравнение без DecimalMode: числа, строки, bool и null без apply
+	insCompareConst                  //insCompare с правым операндом consts[arg]
+	insBinary                        //binaryNode.apply над двумя значениями
+	insMatch                         //matchNode.apply над значением и шаблоном
+	insMember                        //memberNode.apply
+	insIndex                         //indexNode.apply
+	insSliceLen                      //проверка, что значение под срезом — список или строка
+	insSliceBound                    //граница среза, arg — флаги bound*
+	insSlice                         //sliceNode.cut
+	insList                          //список из arg значений
+	insMap                           //словарь из значений для ключей mapNode
+	insLookup                        //поиск функции callNode
+	insCall                          //вызов функции с arg аргументами
+	insLambda                        //лямбда с телом lambdas[arg]
+	insLet                           //переменная let поверх Namespace
+	insUnscope                       //снять переменную let
+	insPop                           //снять значение со стека
+	insJump                          //перейти к arg
+	insBranch                        //снять условие ?: и перейти к arg, если оно false
+	insTry                           //начало левой части ??, обработчик — arg
+	insEndTry                        //левая часть ?? выполнена без ошибок
+	insCatch                         //ошибка левой части ??: null или ошибка дальше
+	insJumpNotNull                   //перейти к arg, если на вершине не null
+	insLogic                         //левый операнд && или ||: перейти к arg, если он решает результат
+	insBool                          //проверить, что правый операнд && или || — bool
+)
+
+const (
+	boundTo      = 1 << iota //верхняя граница, иначе нижняя
+	boundOmitted             //граница опущена
+)
+
+type instr struct {
+	op   uint8
+	arg  int
+	node node //узел, чью семантику выполняет инструкция
+}
+
+// chunk — скомпилированное выражение или тело лямбды.
+type chunk struct {
+	code    []instr
+	consts  []value
+	lambdas []*chunk
+}
+
+func compile(n node) *chunk {
+	c := &chunk{}
+	c.compile(n)
+	return c
+}
+
+func (c *chunk) emit(op uint8, arg int, n node) int {
+	c.code = append(c.code, instr{op, arg, n})
+	return len(c.code) - 1
+}
+
+// patch направляет переход в инструкции i на следующую инструкцию.
+func (c *chunk) patch(i int) { c.code[i].arg = len(c.code) }
+
+func (c *chunk) constant(val any) {
+	res, err := toValue(val)
+	if err != nil {
+		c.consts = append(c.consts, value{kind: valRef, ref: err})
+		c.emit(insFail, len(c.consts)-1, nil)
+		return
+	}
+	c.consts = append(c.consts, res)
+	c.emit(insConst, len(c.consts)-1, nil)
+}
+
+// binaryOp выбирает инструкцию бинарного оператора: в DecimalMode числа
+// остаются Decimal, поэтому типизированные инструкции не используются.
+func binaryOp(n *binaryNode) uint8 {
+	if n.dec != nil {
+		return insBinary
+	}
+	switch n.op {
+	case addOp, subOp, mulOp, divOp, floorDivOp, modOp:
+		return insArith
+	case eqOp, notEqOp, lessOp, lessEqOp, moreOp, moreEqOp:
+		return insCompare
+	default:
+		return insBinary
+	}
+}
+
+// sequence компилирует значение под индексом или срезом.
+func (c *chunk) sequence(n node) {
+	if ident, ok := n.(*identNode); ok {
+		c.emit(insLoadSequence, 0, ident)
+		return
+	}
+	c.compile(n)
+}
+
+func (c *chunk) compile(n node) {
+	switch n := n.(type) {
+	case *numNode, *intNode, *decNode, *strNode, *nullNode, *constNode, *errNode:
+		c.constant(n.exec(nil))
+
+	case *identNode:
+		c.emit(insLoad, 0, n)
+
+	case *unaryNode:
+		c.compile(n.val)
+		c.emit(insUnary, 0, n)
+
+	case *binaryNode:
+		if n.op == andOp || n.op == orOp {
+			c.compile(n.left)
+			logic := c.emit(insLogic, 0, n)
+			c.compile(n.right)
+			c.emit(insBool, 0, n)
+			c.patch(logic)
+			break
+		}
+
+		c.compile(n.left)
+		op := binaryOp(n)
+		//константа справа не кладётся на стек, а берётся из consts
+		if op != insBinary && isConst(n.right) {
+			if val, err := toValue(n.right.exec(nil)); err == nil {
+				c.consts = append(c.consts, val)
+				if op == insArith {
+					op = insArithConst
+				} else {
+					op = insCompareConst
+				}
+				c.emit(op, len(c.consts)-1, n)
+				break
+			}
+		}
+		c.compile(n.right)
+		c.emit(op, 0, n)
+
+	case *matchNode:
+		c.compile(n.val)
+		if n.re == nil {
+			c.compile(n.pattern)
+		} else {
+			c.constant(nil)
+		}
+		c.emit(insMatch, 0, n)
+
+	case *ternaryNode:
+		c.compile(n.cond)
+		branch := c.emit(insBranch, 0, n)
+		c.compile(n.ifTrue)
+		jump := c.emit(insJump, 0, nil)
+		c.patch(branch)
+		c.compile(n.ifFalse)
+		c.patch(jump)
+
+	case *coalesceNode:
+		try := c.emit(insTry, 0, nil)
+		c.compile(n.left)
+		c.emit(insEndTry, 0, nil)
+		jump := c.emit(insJump, 0, nil)
+		c.patch(try)
+		c.emit(insCatch, 0, n)
+		c.patch(jump)
+		end := c.emit(insJumpNotNull, 0, nil)
+		c.emit(insPop, 0, nil)
+		c.compile(n.right)
+		c.patch(end)
+
+	case *listNode:
+		for _, item := range n.items {
+			c.compile(item)
+		}
+		c.emit(insList, len(n.items), n)
+
+	case *mapNode:
+		for _, val := range n.vals {
+			c.compile(val)
+		}
+		c.emit(insMap, len(n.vals), n)
+
+	case *memberNode:
+		c.compile(n.val)
+		c.emit(insMember, 0, n)
+
+	case *indexNode:
+		c.sequence(n.val)
+		c.compile(n.index)
+		c.emit(insIndex, 0, n)
+
+	case *sliceNode:
+		c.sequence(n.val)
+		c.emit(insSliceLen, 0, n)
+		for i, b := range []node{n.from, n.to} {
+			flags := 0
+			if i == 1 {
+				flags |= boundTo
+			}
+			if b == nil {
+				flags |= boundOmitted
+			} else {
+				c.compile(b)
+			}
+			c.emit(insSliceBound, flags, n)
+		}
+		c.emit(insSlice, 0, n)
+
+	case *callNode:
+		c.emit(insLookup, 0, n)
+		for _, arg := range n.args {
+			c.compile(arg)
+		}
+		c.emit(insCall, len(n.args), n)
+
+	case *lambdaNode:
+		c.lambdas = append(c.lambdas, compile(n.body))
+		c.emit(insLambda, len(c.lambdas)-1, n)
+
+	case *letNode:
+		c.compile(n.val)
+		c.emit(insLet, 0, n)
+		c.compile(n.body)
+		c.emit(insUnscope, 0, nil)
+
+	case *seqNode:
+		c.compile(n.first)
+		c.emit(insPop, 0, nil)
+		c.compile(n.rest)
+
+	default:
+		panic("calc: compile: неизвестный узел")
+	}
+}
+
+// handler — активная левая часть ??, в которую передаётся ошибка.
+type handler struct {
+	pc        int
+	sp        int
+	namespace Namespace
+}
+
+// run выполняет байткод. lim равен nil, если ограничений нет.
+func (c *chunk) run(namespace Namespace, lim *limits) any {
+	var buf [8]value
+	stack := buf[:0]
+	var handlers []handler
+	var caught error
+	//err равен nil в начале каждой инструкции, res задаёт каждая инструкция без ошибки
+	var (
+		res value
+		err error
+		ok  bool
+	)
+
+	for pc := 0; pc < len(c.code); pc++ {
+		in := &c.code[pc]
+
+		if lim != nil {
+			//ошибки ограничений не перехватываются ??
+			if err := lim.step(in); err != nil {
+				return err
+			}
+		}
+
+		switch in.op {
+		case insConst:
+			stack = append(stack, c.consts[in.arg])
+			continue
+
+		case insLoad:
+			if res, err = toValue(in.node.(*identNode).exec(namespace)); err == nil {
+				stack = append(stack, res)
+				continue
+			}
+
+		//типизированные инструкции заменяют операнд на вершине стека результатом
+		//и не проходят проверки ограничений: число не бывает длинной строкой или списком
+
+		case insUnary:
+			top := len(stack) - 1
+			n, val := in.node.(*unaryNode), stack[top]
+			switch {
+			case n.op == notOp && val.kind == valBool:
+				stack[top] = boolValue(!val.bool())
+				continue
+			case n.op == subOp && val.kind == valFloat:
+				stack[top] = floatValue(-val.float())
+				continue
+			case n.op == subOp && val.kind == valInt && val.int() != math.MinInt64:
+				stack[top] = intValue(-val.int())
+				continue
+			}
+			stack = stack[:top]
+			res, err = toValue(n.apply(val.box()))
+
+		case insArith, insArithConst, insCompare, insCompareConst:
+			top := len(stack) - 1
+			var r value
+			if in.op == insArithConst || in.op == insCompareConst {
+				r = c.consts[in.arg]
+			} else {
+				r, top = stack[top], top-1
+			}
+
+			n, l := in.node.(*binaryNode), stack[top]
+			if in.op == insArith || in.op == insArithConst {
+				res, ok = arith(n.op, l, r)
+			} else {
+				res, ok = compare(n.op, l, r)
+			}
+			if ok {
+				stack[top], stack = res, stack[:top+1]
+				continue
+			}
+			stack = stack[:top]
+			res, err = toValue(n.apply(l.box(), r.box()))
+
+		case insLet:
+			namespace = &scope{namespace, in.node.(*letNode).name, stack[len(stack)-1].box()}
+			stack = stack[:len(stack)-1]
+			continue
+
+		case insUnscope:
+			namespace = namespace.(*scope).parent
+			continue
+
+		case insPop:
+			stack = stack[:len(stack)-1]
+			continue
+
+		case insJump:
+			pc = in.arg - 1
+			continue
+
+		case insBranch:
+			cond := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if cond.kind != valBool {
+				_, err = in.node.(*ternaryNode).branch(cond.box())
+				break
+			}
+			if !cond.bool() {
+				pc = in.arg - 1
+			}
+			continue
+
+		case insTry:
+			handlers = append(handlers, handler{in.arg, len(stack), namespace})
+			continue
+
+		case insEndTry:
+			handlers = handlers[:len(handlers)-1]
+			continue
+
+		case insCatch:
+			res = value{}
+			if !isMissing(in.node.(*coalesceNode).left, caught) {
+				err = caught
+			}
+
+		case insJumpNotNull:
+			if stack[len(stack)-1].kind != valNull {
+				pc = in.arg - 1
+			}
+			continue
+
+		case insLogic, insBool:
+			n, val := in.node.(*binaryNode), stack[len(stack)-1]
+			if val.kind != valBool {
+				_, err = n.operand(val.box())
+				break
+			}
+			if in.op == insBool {
+				continue
+			}
+			if n.decided(val.bool()) {
+				pc = in.arg - 1
+				continue
+			}
+			stack = stack[:len(stack)-1]
+			continue
+
+		case insSliceLen:
+			if _, err = in.node.(*sliceNode).length(stack[len(stack)-1].box()); err == nil {
+				continue
+			}
+
+		default:
+			res, stack, err = c.apply(in, stack, namespace, lim)
+		}
+
+		if lim != nil && err == nil && res.kind == valRef {
+			switch in.op {
+			case insArith, insArithConst, insBinary, insCall, insList, insMap:
+				if err := lim.check(in, res.ref); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err != nil {
+			if len(handlers) == 0 {
+				return err
+			}
+
+			h := handlers[len(handlers)-1]
+			handlers = handlers[:len(handlers)-1]
+			stack, namespace, caught, err = stack[:h.sp], h.namespace, err, nil
+			pc = h.pc - 1
+			continue
+		}
+
+		stack = append(stack, res)
+	}
+
+	return stack[len(stack)-1].box()
+}
+
+// apply выполняет инструкции, которым не нужны переходы и обработчики ??.
+// они вынесены из run, чтобы цикл run с частыми инструкциями оставался коротким.
+func (c *chunk) apply(in *instr, stack []value, namespace Namespace, lim *limits) (res value, _ []value, err error) {
+	switch in.op {
+	case insFail:
+		return value{}, stack, c.consts[in.arg].ref.(error)
+
+	case insBinary:
+		top := len(stack) - 2
+		res, err = toValue(in.node.(*binaryNode).apply(stack[top].box(), stack[top+1].box()))
+		stack = stack[:top]
+
+	case insMatch:
+		top := len(stack) - 2
+		res, err = toValue(in.node.(*matchNode).apply(stack[top].box(), stack[top+1].box()))
+		stack = stack[:top]
+
+	case insLoadSequence:
+		res, err = toValue(in.node.(*identNode).sequence(namespace))
+
+	case insMember:
+		top := len(stack) - 1
+		res, err = toValue(in.node.(*memberNode).apply(stack[top].box()))
+		stack = stack[:top]
+
+	case insIndex:
+		top := len(stack) - 2
+		res, err = toValue(in.node.(*indexNode).apply(stack[top].box(), stack[top+1].box()))
+		stack = stack[:top]
+
+	case insSliceBound:
+		var b value
+		if in.arg&boundOmitted == 0 {
+			b, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		}
+
+		depth := 1
+		if in.arg&boundTo != 0 {
+			depth = 2
+		}
+		length, _ := sequenceLen(stack[len(stack)-depth].box())
+
+		switch {
+		case in.arg&boundOmitted == 0:
+			var i int
+			i, err = in.node.(*sliceNode).index(b.box(), length)
+			res = intValue(int64(i))
+		case in.arg&boundTo != 0:
+			res = intValue(int64(length))
+		default:
+			res = intValue(0)
+		}
+
+	case insSlice:
+		top := len(stack) - 3
+		res, err = toValue(in.node.(*sliceNode).cut(stack[top].box(), int(stack[top+1].int()), int(stack[top+2].int())))
+		stack = stack[:top]
+
+	case insList:
+		top := len(stack) - in.arg
+		list := make([]any, in.arg)
+		for i, val := range stack[top:] {
+			list[i] = val.box()
+		}
+		res, stack = value{kind: valRef, ref: list}, stack[:top]
+
+	case insMap:
+		top := len(stack) - in.arg
+		obj := make(map[string]any, in.arg)
+		for i, key := range in.node.(*mapNode).keys {
+			obj[key] = stack[top+i].box()
+		}
+		res, stack = value{kind: valRef, ref: obj}, stack[:top]
+
+	case insLookup:
+		var f callable
+		if f, err = in.node.(*callNode).lookup(namespace); err == nil {
+			res = value{kind: valRef, ref: f}
+		}
+
+	case insCall:
+		top := len(stack) - in.arg
+		args := make([]any, in.arg)
+		for i, val := range stack[top:] {
+			args[i] = val.box()
+		}
+		f, n := stack[top-1].ref.(callable), in.node.(*callNode)
+		stack = stack[:top-1]
+		if fn, ok := f.(*function); ok {
+			res, err = toValue(fn.callWith(n.pos, args, lim, n.dec))
+		} else {
+			res, err = toValue(f.call(n.pos, args))
+		}
+
+	case insLambda:
+		n := in.node.(*lambdaNode)
+		res = value{kind: valRef, ref: &lambda{n.params, n.body, namespace, n.pos, c.lambdas[in.arg], lim}}
+	}
+	return res, stack, err
+}
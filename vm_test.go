@@ -0,0 +1,120 @@
+package calc
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Test_run сравнивает байткод с выполнением дерева, включая ошибки.
+func Test_run(t *testing.T) {
+	ns := Chain(objects, base, namespace{
+		"xs":   []any{int64(1), int64(2), int64(3)},
+		"none": nil,
+		"inc":  func(x int) int { return x + 1 },
+	})
+
+	programs := []string{
+		"age * 2 + 1",
+		"age > 18 ? name : 'child'",
+		"age > 18 ? missing : name",
+		"age ? 1 : 2",
+		"[age, name, [xs[0]]]",
+		"{'a': age, b: [name]}",
+		"user.Address.City",
+		"user.Address.Zip",
+		"user?.Manager?.Manager?.Name ?? 'никто'",
+		"missing ?? none ?? 3",
+		"len(missing) ?? 0",
+		"xs[1:] + 1",
+		"xs[-2:][0]",
+		"name[1:3]",
+		"5[missing:]",
+		"xs['a':missing]",
+		"xs[:missing]",
+		"name =~ '^ty' && name !~ age",
+		"inc(age) + inc(1)",
+		"nope(missing)",
+		"inc(missing)",
+		"map(xs, (x, i) => x * i + age)",
+		"reduce(xs, (acc, x) => acc + x, 0)",
+		"filter(xs, x => x > missing)",
+		"let k = 2; let f = x => x * k; map(xs, f)",
+		"let k = 2; k + 1; k * age",
+		"let f = x => x ?? 0; f(none) + f(1)",
+		"(missing ?? xs)[0] ?? age",
+		"[1, missing ?? 2, 3]",
+		"sum(xs) ?? 0",
+		"is_admin || missing",
+		"!is_admin && missing",
+		"is_admin && age",
+		"age || is_admin",
+		"(is_admin && missing) ?? 1",
+		"age * 1.5 - 7 // 2 + age % 5 - 1.5 % age",
+		"age / 0",
+		"age % 0.0",
+		"age // 0 ?? 1",
+		"(age - age - 9223372036854775807 - 1) // -1",
+		"age + 9223372036854775807",
+		"-(age - age - 9223372036854775807 - 1)",
+		"-age + -1.5 + -xs[0]",
+		"!is_admin",
+		"-name",
+		"name + name",
+		"name + 1",
+		"age == 32.0 && age != 33 && 1.5 < age",
+		"name < 'z' && name >= 'a' && none == null && is_admin != true",
+		"none != age && null == none",
+		"is_admin < true",
+		"xs == [1, 2, 3]",
+	}
+
+	for _, src := range programs {
+		p, err := Compile(src)
+		if err != nil {
+			t.Errorf("%s: %v", src, err)
+			continue
+		}
+
+		tree, code := p.root.exec(ns), p.code.run(ns, nil)
+		if !reflect.DeepEqual(code, tree) {
+			t.Errorf("%s: got %#v, want %#v", src, code, tree)
+		}
+	}
+}
+
+const benchProgram = "age >= 18 && name != 'paul' ? (age * 2 + 1) % 7 : len(name)"
+
+func Benchmark_Eval(b *testing.B) {
+	p := MustCompile(benchProgram)
+	ns := namespace{"name": "tyson", "age": 32}
+
+	for i := 0; i < b.N; i++ {
+		if _, err := p.Eval(ns); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// Benchmark_exec — то же выражение без байткода, для сравнения с Benchmark_Eval.
+func Benchmark_exec(b *testing.B) {
+	p := MustCompile(benchProgram)
+	ns := namespace{"name": "tyson", "age": 32}
+
+	for i := 0; i < b.N; i++ {
+		if _, ok := p.root.exec(ns).(error); ok {
+			b.Fatal("unexpected error")
+		}
+	}
+}
+
+// Benchmark_index — элемент большого среза Go из Namespace.
+func Benchmark_index(b *testing.B) {
+	p := MustCompile("xs[0]")
+	ns := namespace{"xs": make([]int, 100_000)}
+
+	for i := 0; i < b.N; i++ {
+		if _, err := p.Eval(ns); err != nil {
+			b.Fatal(err)
+		}
+	}
+}